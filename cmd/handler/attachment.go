@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/attachment"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Attachment struct {
+	attachmentService attachment.Service
+}
+
+func NewAttachmentHandler(a attachment.Service) *Attachment {
+	return &Attachment{
+		attachmentService: a,
+	}
+}
+
+func (h *Attachment) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		attachments, err := h.attachmentService.GetAll(c, productID)
+		if err != nil {
+			web.Failure(c, attachmentErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, attachments)
+	}
+}
+
+func (h *Attachment) Download() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get IDs from path params
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		id, err := strconv.Atoi(c.Param("attachment_id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		found, content, err := h.attachmentService.Content(c, productID, id)
+		if err != nil {
+			web.Failure(c, attachmentErrorStatus(err), err)
+			return
+		}
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": found.FileName}))
+		c.Header("X-Checksum-Sha256", found.Checksum)
+		c.Data(http.StatusOK, found.ContentType, content)
+	}
+}
+
+func (h *Attachment) Upload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		//Files come in the file field of a multipart form
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxSize()+1<<20)
+		header, err := c.FormFile("file")
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, err)
+			return
+		}
+		if header.Size > h.attachmentService.MaxSize() {
+			web.Failure(c, http.StatusRequestEntityTooLarge, attachment.ErrTooLarge)
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, err)
+			return
+		}
+		created, err := h.attachmentService.Upload(c, productID, header.Filename, content)
+		if err != nil {
+			web.Failure(c, attachmentErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *Attachment) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get IDs from path params
+		productID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		id, err := strconv.Atoi(c.Param("attachment_id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = h.attachmentService.Delete(c, productID, id)
+		if err != nil {
+			web.Failure(c, attachmentErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// maps attachment service errors to response status codes
+func attachmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, attachment.ErrNotFound), errors.Is(err, product.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, attachment.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, attachment.ErrContentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, attachment.ErrCreatingAttachment), errors.Is(err, attachment.ErrReadingAttachment), errors.Is(err, attachment.ErrChecksum):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// builds a multipart upload of content under the file field
+func createUploadTest(url string, fileName string, content []byte, token string) (*http.Request, *httptest.ResponseRecorder) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write(content)
+	writer.Close()
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.Header.Add("TOKEN", token)
+	return req, httptest.NewRecorder()
+}
+
+func Test_Attachments_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./attachments_copy.json", []byte("[]"), 0644)
+		_ = os.RemoveAll("./attachments_copy")
+	}()
+
+	r := createServer("my-secret-token")
+	picture := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+	req, rr := createUploadTest("/products/1/attachments", "../photos/front.png", picture, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	created := map[string]domain.Attachment{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	sum := sha256.Sum256(picture)
+	assert.Equal(t, "front.png", created["data"].FileName)
+	assert.Equal(t, "image/png", created["data"].ContentType)
+	assert.Equal(t, int64(len(picture)), created["data"].Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), created["data"].Checksum)
+
+	//The content type comes from the content, not the file name
+	req, rr = createUploadTest("/products/1/attachments", "specs.pdf", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	req, rr = createUploadTest("/products/1/attachments", "specs.txt", []byte(strings.Repeat("a", 2048)), "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	req, rr = createUploadTest("/products/999/attachments", "front.png", picture, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/attachments", "", "")
+	r.ServeHTTP(rr, req)
+	listed := map[string][]domain.Attachment{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	assert.Len(t, listed["data"], 1)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/attachments/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, picture, rr.Body.Bytes())
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=front.png`, rr.Header().Get("Content-Disposition"))
+
+	//Purging the product removes its attachments
+	req, rr = createRequestTest(http.MethodDelete, "/products/1", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/products/trash/1", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodGet, "/products/1/attachments/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	_, err = os.Stat("./attachments_copy/1")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/audit"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+// MaxAuditedBody is the largest JSON body taken by audited calls, in bytes
+const MaxAuditedBody = 1 << 20
+
+// the number of locks audited calls to products are spread over
+const auditLocks = 64
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+type Audit struct {
+	auditService   audit.Service
+	productService product.Service
+	locks          [auditLocks]sync.Mutex
+}
+
+func NewAuditHandler(a audit.Service, p product.Service) *Audit {
+	return &Audit{
+		auditService:   a,
+		productService: p,
+	}
+}
+
+// Record is a middleware adding every POST, PUT and DELETE to the audit log,
+// along with the product it touched as it was before and after the call. It
+// goes after authentication, so only calls by known actors are logged, and
+// rejects JSON bodies over MaxAuditedBody.
+//
+// Calls to the same product route are recorded one at a time, so their
+// before and after follow each other. Writes reaching the product some other
+// way, like orders taking its stock or the scheduler publishing it, can still
+// land between the two.
+func (h *Audit) Record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		//Keep JSON bodies, putting them back for the handler to read
+		var request json.RawMessage
+		if c.Request.Body != nil && c.ContentType() == gin.MIMEJSON {
+			body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxAuditedBody))
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				web.Failure(c, http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+				c.Abort()
+			case err != nil:
+				web.Failure(c, http.StatusBadRequest, ErrCanNotParse)
+				c.Abort()
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			if err == nil && json.Valid(body) {
+				request = body
+			}
+		}
+		productID := routeProductID(c)
+		if productID != 0 {
+			unlock := h.lock(productID)
+			defer unlock()
+		}
+		before := h.snapshot(c, productID)
+
+		c.Next()
+
+		if productID == 0 {
+			productID = c.GetInt(web.ProductIDKey)
+		}
+		_, err := h.auditService.Record(c, domain.AuditEntry{
+			Actor:     web.Actor(c),
+			ClientIP:  c.ClientIP(),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			ProductID: productID,
+			Request:   request,
+			Before:    before,
+			After:     h.snapshot(c, productID),
+			Status:    c.Writer.Status(),
+		})
+		if err != nil {
+			log.Printf("audit log: %v", err)
+		}
+	}
+}
+
+func (h *Audit) Search() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := domain.AuditFilter{Actor: c.Query("actor")}
+		if c.Query("product_id") != "" {
+			productID, err := strconv.Atoi(c.Query("product_id"))
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+				return
+			}
+			filter.ProductID = productID
+		}
+		for param, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			if c.Query(param) == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, c.Query(param))
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, ErrCanNotParse)
+				return
+			}
+			*value = parsed
+		}
+		entries, err := h.auditService.Search(c, filter)
+		if errors.Is(err, audit.ErrTimeRange) {
+			web.Failure(c, http.StatusBadRequest, err)
+			return
+		}
+		web.Success(c, http.StatusOK, entries)
+	}
+}
+
+// the product a request is about, taken from the route
+func routeProductID(c *gin.Context) int {
+	param := c.Param("product_id")
+	if param == "" && strings.HasPrefix(c.FullPath(), "/products/") {
+		param = c.Param("id")
+	}
+	id, _ := strconv.Atoi(param)
+	return id
+}
+
+// keeps other audited calls to a product out until the returned func is
+// called. Products share a fixed set of locks, so calls to two of them may
+// wait for each other.
+func (h *Audit) lock(productID int) func() {
+	mu := &h.locks[uint(productID)%auditLocks]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// the product with the given ID, in the trash or not, if there is one
+func (h *Audit) snapshot(c *gin.Context, productID int) *domain.Product {
+	if productID == 0 {
+		return nil
+	}
+	found, err := h.productService.Get(c, productID)
+	if err == nil {
+		return &found
+	}
+	for _, trashed := range h.productService.Trash(c) {
+		if trashed.ID == productID {
+			return &trashed
+		}
+	}
+	return nil
+}
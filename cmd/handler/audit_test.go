@@ -0,0 +1,179 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/cmd/handler"
+	"github.com/hernan-hdiaz/go-web/internal/audit"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Audit_Log_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.WriteFile("./audit_copy.json", []byte("[]"), 0644)
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	//The actor comes from the token, not from what the caller claims
+	req, rr := createRequestTest(http.MethodPut, "/products/4", `{"name":"Audited"}`, "alice-token")
+	req.Header.Set("actor", "mallory")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products", `{"name":"Broken"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products", `{"name":"Audited too","quantity":1,"code_value":"AUDIT-1","expiration":"01/01/2024","price":10}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	created := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	//Reads are not audited
+	req, rr = createRequestTest(http.MethodGet, "/products/4", "", "")
+	r.ServeHTTP(rr, req)
+
+	req, rr = createRequestTest(http.MethodGet, "/audit", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	entries := map[string][]domain.AuditEntry{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Len(t, entries["data"], 3)
+	assert.Equal(t, domain.AuditOutcomeFailure, entries["data"][1].Outcome)
+	assert.Equal(t, http.StatusUnprocessableEntity, entries["data"][1].Status)
+	assert.Equal(t, created["data"].ID, entries["data"][2].ProductID)
+	assert.Nil(t, entries["data"][2].Before)
+
+	req, rr = createRequestTest(http.MethodGet, "/audit?actor=alice&product_id=4", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Len(t, entries["data"], 1)
+	entry := entries["data"][0]
+	assert.Equal(t, "alice", entry.Actor)
+	assert.Equal(t, "/products/:id", entry.Route)
+	assert.Equal(t, http.MethodPut, entry.Method)
+	assert.Equal(t, domain.AuditOutcomeSuccess, entry.Outcome)
+	assert.JSONEq(t, `{"name":"Audited"}`, string(entry.Request))
+	assert.Equal(t, p[3].Name, entry.Before.Name)
+	assert.Equal(t, "Audited", entry.After.Name)
+
+	req, rr = createRequestTest(http.MethodGet, "/audit?from=2999-01-01T00:00:00Z", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Len(t, entries["data"], 0)
+	req, rr = createRequestTest(http.MethodGet, "/audit?from=2999-01-01T00:00:00Z&to=2000-01-01T00:00:00Z", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_Audit_Unauthorized(t *testing.T) {
+	_ = os.WriteFile("./audit_copy.json", []byte("[]"), 0644)
+	auditService := audit.NewService(audit.NewRepository(store.NewAuditStore("./audit_copy.json")))
+	auditHandler := handler.NewAuditHandler(auditService, nil)
+	r := gin.New()
+	r.Use(web.Authenticate(web.Credentials{"alice-token": "alice"}))
+	r.Use(auditHandler.Record())
+	r.POST("/products", func(c *gin.Context) {
+		web.Success(c, http.StatusCreated, nil)
+	})
+
+	//Unknown callers are turned away before anything is kept
+	req, rr := createRequestTest(http.MethodPost, "/products", `{}`, "not-my-token")
+	req.Header.Set("actor", "alice")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products", `{}`, "alice-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	//And bodies too large to keep are rejected
+	large := fmt.Sprintf(`{"name":%q}`, strings.Repeat("a", handler.MaxAuditedBody))
+	req, rr = createRequestTest(http.MethodPost, "/products", large, "alice-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	entries, err := auditService.Search(context.Background(), domain.AuditFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, entries[1].Status)
+	assert.Nil(t, entries[1].Request)
+}
+
+func Test_Audit_Snapshots(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.WriteFile("./audit_copy.json", []byte("[]"), 0644)
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	r := createServer("my-secret-token")
+	//Concurrent calls to a product each see their own before and after
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, rr := createRequestTest(http.MethodPut, "/products/4", fmt.Sprintf(`{"name":"Audited %d"}`, i), "my-secret-token")
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+		}(i)
+	}
+	wg.Wait()
+	req, rr := createRequestTest(http.MethodGet, "/audit?product_id=4", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	entries := map[string][]domain.AuditEntry{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Len(t, entries["data"], 5)
+	previous := p[3].Name
+	for _, entry := range entries["data"] {
+		request := domain.ProductRequest{}
+		assert.Nil(t, json.Unmarshal(entry.Request, &request))
+		assert.Equal(t, previous, entry.Before.Name)
+		assert.Equal(t, request.Name, entry.After.Name)
+		previous = entry.After.Name
+	}
+
+	//Products in the trash are still snapshotted when restored and purged
+	_ = os.WriteFile("./audit_copy.json", []byte("[]"), 0644)
+	for _, step := range []struct {
+		method string
+		url    string
+	}{
+		{http.MethodDelete, "/products/4"},
+		{http.MethodPost, "/products/trash/4/restore"},
+		{http.MethodDelete, "/products/4"},
+		{http.MethodDelete, "/products/trash/4"},
+	} {
+		req, rr := createRequestTest(step.method, step.url, "", "my-secret-token")
+		r.ServeHTTP(rr, req)
+		assert.Less(t, rr.Code, 300, step.method+" "+step.url)
+	}
+	req, rr = createRequestTest(http.MethodGet, "/audit?product_id=4", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Len(t, entries["data"], 4)
+	restore, purge := entries["data"][1], entries["data"][3]
+	assert.NotNil(t, restore.Before)
+	assert.NotNil(t, restore.Before.DeletedAt)
+	assert.Nil(t, restore.After.DeletedAt)
+	assert.NotNil(t, purge.Before)
+	assert.Nil(t, purge.After)
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/cart"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Cart struct {
+	cartService cart.Service
+}
+
+func NewCartHandler(s cart.Service) *Cart {
+	return &Cart{
+		cartService: s,
+	}
+}
+
+func (h *Cart) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		found, err := h.cartService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, found)
+	}
+}
+
+func (h *Cart) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cartRequest domain.CartRequest
+		if err := c.ShouldBindJSON(&cartRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		created, err := h.cartService.Create(c, cartRequest)
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *Cart) AddLine() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var lineRequest domain.LineRequest
+		if err := c.ShouldBindJSON(&lineRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := h.cartService.AddLine(c, id, lineRequest)
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Cart) UpdateLine() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get IDs from path params
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("product_id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var lineRequest domain.CartLineRequest
+		if err := c.ShouldBindJSON(&lineRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		//Lines in a pack are told apart by its unit
+		updated, err := h.cartService.UpdateLine(c, id, productID, c.Param("unit"), lineRequest.Quantity)
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Cart) RemoveLine() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get IDs from path params
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		productID, err := strconv.Atoi(c.Param("product_id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		//Lines in a pack are told apart by its unit
+		updated, err := h.cartService.RemoveLine(c, id, productID, c.Param("unit"))
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Cart) Price() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		quote, err := h.cartService.Price(c, id)
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, quote)
+	}
+}
+
+func (h *Cart) Checkout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		created, err := h.cartService.Checkout(c, id)
+		if err != nil {
+			web.Failure(c, cartErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+// maps cart service errors to response status codes
+func cartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, cart.ErrNotFound), errors.Is(err, cart.ErrLineNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, cart.ErrCheckedOut):
+		return http.StatusConflict
+	case errors.Is(err, cart.ErrCreatingCart), errors.Is(err, cart.ErrUpdatingCart):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,183 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cart_Checkout_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	steps := []struct {
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/carts", `{}`, http.StatusCreated},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1}`, http.StatusOK},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1}`, http.StatusOK},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":2,"quantity":1}`, http.StatusOK},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":3,"quantity":1}`, http.StatusBadRequest},
+		{http.MethodPut, "/carts/1/lines/2", `{"quantity":3}`, http.StatusOK},
+		{http.MethodDelete, "/carts/1/lines/2", ``, http.StatusOK},
+		{http.MethodDelete, "/carts/1/lines/2", ``, http.StatusNotFound},
+	}
+	for _, step := range steps {
+		req, rr := createRequestTest(step.method, step.url, step.body, "")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, step.status, rr.Code, step.method+" "+step.url)
+	}
+
+	req, rr := createRequestTest(http.MethodGet, "/carts/1/price", "", "")
+	r.ServeHTTP(rr, req)
+	quote := map[string]domain.Quote{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &quote))
+	assert.Equal(t, 2, quote["data"].Lines[0].Quantity)
+
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/checkout", "", "")
+	r.ServeHTTP(rr, req)
+	order := map[string]domain.Order{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &order))
+	assert.Equal(t, quote["data"].TotalPrice, order["data"].TotalPrice)
+
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/checkout", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Cart_Checkout_Concurrent(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/carts", `{}`, "")
+	r.ServeHTTP(rr, req)
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	//Only one of the checkouts of the same cart gets an order
+	codes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, rr := createRequestTest(http.MethodPost, "/carts/1/checkout", "", "")
+			r.ServeHTTP(rr, req)
+			codes[i] = rr.Code
+		}(i)
+	}
+	wg.Wait()
+	created := 0
+	for _, code := range codes {
+		if code == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	req, rr = createRequestTest(http.MethodGet, "/orders", "", "")
+	r.ServeHTTP(rr, req)
+	orders := map[string][]domain.Order{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &orders))
+	assert.Len(t, orders["data"], 1)
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-1, after[0].Quantity)
+}
+
+func Test_Cart_Checkout_OrderFails(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/carts", `{}`, "")
+	r.ServeHTTP(rr, req)
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	//The stock runs out after the line was added, so no order can be placed
+	empty, _ := loadProducts("./products_copy.json")
+	empty[0].Quantity = 0
+	assert.Nil(t, writeProducts("./products_copy.json", empty))
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/checkout", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	//The cart is left open and checks out once there is stock again
+	req, rr = createRequestTest(http.MethodGet, "/carts/1", "", "")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.Cart{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, domain.CartStatusOpen, found["data"].Status)
+	assert.Zero(t, found["data"].OrderID)
+
+	assert.Nil(t, writeProducts("./products_copy.json", p))
+	req, rr = createRequestTest(http.MethodPost, "/carts/1/checkout", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func Test_Cart_Lines_ByUnit(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	packed, _ := loadProducts("./products_copy.json")
+	packed[0].PackSizes = []domain.PackSize{{Unit: "case", Factor: 6}}
+	assert.Nil(t, writeProducts("./products_copy.json", packed))
+
+	r := createOrderServer()
+	steps := []struct {
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/carts", `{}`, http.StatusCreated},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":2}`, http.StatusOK},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1,"unit":"unit"}`, http.StatusOK},
+		{http.MethodPost, "/carts/1/lines", `{"product_id":1,"quantity":1,"unit":"case"}`, http.StatusOK},
+		{http.MethodPut, "/carts/1/lines/1/unit", `{"quantity":4}`, http.StatusOK},
+		{http.MethodPut, "/carts/1/lines/1/case", `{"quantity":3}`, http.StatusOK},
+		{http.MethodDelete, "/carts/1/lines/1", ``, http.StatusOK},
+		{http.MethodDelete, "/carts/1/lines/1", ``, http.StatusNotFound},
+		{http.MethodPut, "/carts/1/lines/1/box", `{"quantity":3}`, http.StatusNotFound},
+	}
+	for _, step := range steps {
+		req, rr := createRequestTest(step.method, step.url, step.body, "")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, step.status, rr.Code, step.method+" "+step.url)
+	}
+
+	//The base unit, empty or spelled out, is a single line, so it is removed
+	//once and only the case line is left, with the quantity it was set to
+	req, rr := createRequestTest(http.MethodGet, "/carts/1", "", "")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.Cart{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, []domain.LineRequest{{ProductID: 1, Quantity: 3, Unit: "case"}}, found["data"].Lines)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/category"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Category struct {
+	categoryService category.Service
+}
+
+func NewCategoryHandler(s category.Service) *Category {
+	return &Category{
+		categoryService: s,
+	}
+}
+
+func (h *Category) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categories := h.categoryService.GetAll(c)
+		web.Success(c, http.StatusOK, categories)
+	}
+}
+
+func (h *Category) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		found, err := h.categoryService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, found)
+	}
+}
+
+func (h *Category) Save() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var categoryRequest domain.Category
+		if err := c.ShouldBindJSON(&categoryRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		created, err := h.categoryService.Save(c, categoryRequest)
+		if err != nil {
+			web.Failure(c, categoryErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *Category) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var categoryRequest domain.Category
+		if err := c.ShouldBindJSON(&categoryRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := h.categoryService.Update(c, categoryRequest, id)
+		if err != nil {
+			web.Failure(c, categoryErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Category) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = h.categoryService.Delete(c, id)
+		if err != nil {
+			web.Failure(c, categoryErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// maps category service errors to response status codes
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, category.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, category.ErrHasChildren), errors.Is(err, category.ErrInUse), errors.Is(err, category.ErrCycle):
+		return http.StatusConflict
+	case errors.Is(err, category.ErrParentNotFound), errors.Is(err, gtin.ErrUnknownFormat):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
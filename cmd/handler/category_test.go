@@ -0,0 +1,118 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Category_Filter_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./categories_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/categories", `{"name":"Wine"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/categories", `{"name":"Red","parent_id":1}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPut, "/products/1", `{"category_id":2,"tags":[" Dry","organic","dry"]}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	updated := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, []string{"dry", "organic"}, updated["data"].Tags)
+
+	//Filtering by the parent category includes its subcategories
+	req, rr = createRequestTest(http.MethodGet, "/products?category_id=1&tags=DRY", "", "")
+	r.ServeHTTP(rr, req)
+	filtered := map[string][]domain.Product{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &filtered))
+	assert.Len(t, filtered["data"], 1)
+	assert.Equal(t, 1, filtered["data"][0].ID)
+
+	req, rr = createRequestTest(http.MethodGet, "/products?tags=dry,sweet", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &filtered))
+	assert.Empty(t, filtered["data"])
+
+	//A category can not be moved under its own subtree nor deleted while in use
+	req, rr = createRequestTest(http.MethodPut, "/categories/1", `{"name":"Wine","parent_id":2}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/categories/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	//Products in the trash keep their category in use until purged
+	req, rr = createRequestTest(http.MethodDelete, "/products/1", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/categories/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Category_CodeFormat_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./categories_copy.json", []byte("[]"), 0644)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/categories", `{"name":"Groceries","code_format":"ean13"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//A UPC-A typo fails its check digit
+	body := `{"name":"Soda","quantity":10,"code_value":"036000291453","expiration":"01/01/2024","price":1.5,"category_id":1}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	//Short numbers are not padded into codes
+	body = `{"name":"Soda","quantity":10,"code_value":"17","expiration":"01/01/2024","price":1.5,"category_id":1}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	//A valid UPC-A is stored as EAN-13
+	body = `{"name":"Soda","quantity":10,"code_value":" 036000291452","expiration":"01/01/2024","price":1.5,"category_id":1}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	created := map[string]domain.Product{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "0036000291452", created["data"].CodeValue)
+
+	//Any GTIN representation resolves to the product
+	req, rr = createRequestTest(http.MethodGet, "/products/lookup?code=00036000291452", "", "")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.Product{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, created["data"].ID, found["data"].ID)
+
+	//And counts as a duplicate code
+	body = `{"name":"Soda","quantity":10,"code_value":"00036000291452","expiration":"01/01/2024","price":1.5}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/currency"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Currency struct {
+	currencyService currency.Service
+}
+
+func NewCurrencyHandler(s currency.Service) *Currency {
+	return &Currency{
+		currencyService: s,
+	}
+}
+
+func (h *Currency) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rates := h.currencyService.GetAll(c)
+		web.Success(c, http.StatusOK, rates)
+	}
+}
+
+func (h *Currency) Save() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rateRequest domain.ExchangeRate
+		if err := c.ShouldBindJSON(&rateRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		//Currency code comes from path param
+		rateRequest.Currency = c.Param("currency")
+		rate, err := h.currencyService.Save(c, rateRequest)
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		web.Success(c, http.StatusOK, rate)
+	}
+}
+
+func (h *Currency) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := h.currencyService.Delete(c, c.Param("currency"))
+		if errors.Is(err, currency.ErrBaseCurrencyFixed) {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if errors.Is(err, currency.ErrInUse) {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
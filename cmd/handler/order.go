@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/order"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Order struct {
+	orderService order.Service
+}
+
+func NewOrderHandler(s order.Service) *Order {
+	return &Order{
+		orderService: s,
+	}
+}
+
+func (h *Order) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orders := h.orderService.GetAll(c)
+		web.Success(c, http.StatusOK, orders)
+	}
+}
+
+func (h *Order) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		order, err := h.orderService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, order)
+	}
+}
+
+func (h *Order) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var orderRequest domain.OrderRequest
+		if err := c.ShouldBindJSON(&orderRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		createdOrder, err := h.orderService.Create(c, orderRequest)
+		if err != nil {
+			web.Failure(c, orderErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, createdOrder)
+	}
+}
+
+func (h *Order) Ship() gin.HandlerFunc {
+	return h.changeStatus(h.orderService.Ship)
+}
+
+func (h *Order) Cancel() gin.HandlerFunc {
+	return h.changeStatus(h.orderService.Cancel)
+}
+
+func (h *Order) Return() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var returnRequest domain.ReturnRequest
+		if err := c.ShouldBindJSON(&returnRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := h.orderService.Return(c, id, returnRequest)
+		if err != nil {
+			web.Failure(c, orderErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+// builds a handler moving an order to another status with an optional reason
+func (h *Order) changeStatus(change func(ctx context.Context, id int, reason string) (domain.Order, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var statusRequest domain.OrderStatusRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&statusRequest); err != nil {
+				web.Failure(c, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+		updated, err := change(c, id, statusRequest.Reason)
+		if err != nil {
+			web.Failure(c, orderErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+// maps order service errors to response status codes
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, order.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, order.ErrNotCancellable), errors.Is(err, order.ErrNotShippable), errors.Is(err, order.ErrNotReturnable):
+		return http.StatusConflict
+	case errors.Is(err, order.ErrReturnExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, order.ErrCreatingOrder), errors.Is(err, order.ErrUpdatingOrder):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,551 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/cmd/handler"
+	"github.com/hernan-hdiaz/go-web/internal/alert"
+	"github.com/hernan-hdiaz/go-web/internal/cart"
+	"github.com/hernan-hdiaz/go-web/internal/category"
+	"github.com/hernan-hdiaz/go-web/internal/currency"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/ledger"
+	"github.com/hernan-hdiaz/go-web/internal/order"
+	"github.com/hernan-hdiaz/go-web/internal/pricelist"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/purchase"
+	"github.com/hernan-hdiaz/go-web/internal/reservation"
+	"github.com/hernan-hdiaz/go-web/internal/supplier"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func createOrderServer() *gin.Engine {
+	repo := product.NewRepository(store.NewStore("./products_copy.json", store.NewAttachmentStore("./attachments_copy.json", "./attachments_copy"), store.NewRevisionStore("./revisions_copy.json")))
+	supplierRepo := supplier.NewRepository(store.NewSupplierStore("./suppliers_copy.json"))
+	rates := currency.NewService(currency.NewRepository(store.NewRateStore("./exchange_rates_copy.json")), "USD", repo, supplierRepo)
+	rateHandler := handler.NewCurrencyHandler(rates)
+	prices := pricelist.NewService(pricelist.NewRepository(store.NewPriceListStore("./price_lists_copy.json")))
+	movements := ledger.NewService(ledger.NewRepository(store.NewMovementStore("./movements_copy.json")))
+	holds := reservation.NewRepository(store.NewReservationStore("./reservations_copy.json"))
+	warehouses := warehouse.NewService(warehouse.NewRepository(store.NewWarehouseStore("./warehouses_copy.json")), repo)
+	categories := category.NewService(category.NewRepository(store.NewCategoryStore("./categories_copy.json")), repo)
+	products := product.NewService(repo, rates, prices, holds, movements, alert.NewLogAlerter(), warehouses, categories)
+	reservations := reservation.NewService(holds, products)
+	orders := order.NewService(order.NewRepository(store.NewOrderStore("./orders_copy.json")), products, reservations)
+	orderHandler := handler.NewOrderHandler(orders)
+	reservationHandler := handler.NewReservationHandler(reservations)
+	carts := cart.NewService(cart.NewRepository(store.NewCartStore("./carts_copy.json")), products, orders)
+	cartHandler := handler.NewCartHandler(carts)
+	productHandler := handler.NewProductHandler(products, handler.DefaultCacheControl)
+	purchaseRepo := purchase.NewRepository(store.NewPurchaseOrderStore("./purchase_orders_copy.json"))
+	suppliers := supplier.NewService(supplierRepo, products, rates, purchaseRepo)
+	supplierHandler := handler.NewSupplierHandler(suppliers)
+	purchaseHandler := handler.NewPurchaseOrderHandler(purchase.NewService(purchaseRepo, suppliers, products, warehouses))
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+
+	r.POST("/products/bundles", productHandler.CreateBundle())
+	r.GET("/products/margins", productHandler.Margins())
+	r.POST("/suppliers", supplierHandler.Save())
+	r.DELETE("/suppliers/:id", supplierHandler.Delete())
+	r.DELETE("/exchange_rates/:currency", rateHandler.Delete())
+	pr := r.Group("/purchase_orders")
+	{
+		pr.GET(":id", purchaseHandler.Get())
+		pr.POST("", purchaseHandler.Create())
+		pr.POST(":id/receive", purchaseHandler.Receive())
+		pr.POST(":id/cancel", purchaseHandler.Cancel())
+	}
+
+	or := r.Group("/orders")
+	{
+		or.GET("", orderHandler.GetAll())
+		or.GET(":id", orderHandler.Get())
+		or.POST("", orderHandler.Create())
+		or.POST(":id/ship", orderHandler.Ship())
+		or.POST(":id/cancel", orderHandler.Cancel())
+		or.POST(":id/returns", orderHandler.Return())
+	}
+	rr := r.Group("/reservations")
+	{
+		rr.GET(":id", reservationHandler.Get())
+		rr.POST("", reservationHandler.Create())
+		rr.DELETE(":id", reservationHandler.Cancel())
+	}
+	cr := r.Group("/carts")
+	{
+		cr.GET(":id", cartHandler.Get())
+		cr.GET(":id/price", cartHandler.Price())
+		cr.POST("", cartHandler.Create())
+		cr.POST(":id/lines", cartHandler.AddLine())
+		cr.PUT(":id/lines/:product_id", cartHandler.UpdateLine())
+		cr.PUT(":id/lines/:product_id/:unit", cartHandler.UpdateLine())
+		cr.DELETE(":id/lines/:product_id", cartHandler.RemoveLine())
+		cr.DELETE(":id/lines/:product_id/:unit", cartHandler.RemoveLine())
+		cr.POST(":id/checkout", cartHandler.Checkout())
+	}
+	return r
+}
+
+// restores the fixtures an order test writes to
+func restoreOrderFixtures(t *testing.T, products []domain.Product) {
+	assert.Nil(t, writeProducts("./products_copy.json", products))
+	assert.Nil(t, os.WriteFile("./orders_copy.json", []byte("[]"), 0644))
+	assert.Nil(t, os.WriteFile("./reservations_copy.json", []byte("[]"), 0644))
+	assert.Nil(t, os.WriteFile("./carts_copy.json", []byte("[]"), 0644))
+	assert.Nil(t, os.WriteFile("./movements_copy.json", []byte("[]"), 0644))
+	assert.Nil(t, os.WriteFile("./suppliers_copy.json", []byte("[]"), 0644))
+	assert.Nil(t, os.WriteFile("./purchase_orders_copy.json", []byte("[]"), 0644))
+}
+
+func Test_Order_Create_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":2},{"product_id":2,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+
+	actual := map[string]domain.Order{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, 1, actual["data"].ID)
+	assert.Equal(t, domain.OrderStatusCreated, actual["data"].Status)
+	assert.Len(t, actual["data"].Lines, 2)
+	//(71.42 * 2 + 352.79) * 1.21
+	assert.Equal(t, 599.71, actual["data"].TotalPrice)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-2, after[0].Quantity)
+	assert.Equal(t, p[1].Quantity-1, after[1].Quantity)
+
+	req, rr = createRequestTest(http.MethodGet, "/orders/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func Test_Order_Create_AllOrNothing(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	//Product 3 is not published, so product 1 must keep its stock
+	req, rr := createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":1},{"product_id":3,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	//More units than available
+	req, rr = createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":100000}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity, after[0].Quantity)
+
+	req, rr = createRequestTest(http.MethodGet, "/orders", "", "")
+	r.ServeHTTP(rr, req)
+	actual := map[string][]domain.Order{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Empty(t, actual["data"])
+}
+
+func Test_Order_Reservation_Holds_Stock(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	//Hold every unit of product 1
+	body, _ := json.Marshal(domain.ReservationRequest{Lines: []domain.LineRequest{{ProductID: 1, Quantity: p[0].Quantity}}, TTLSeconds: 60})
+	req, rr := createRequestTest(http.MethodPost, "/reservations", string(body), "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//Nobody else can buy them
+	req, rr = createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	//The holder can, which consumes the reservation
+	req, rr = createRequestTest(http.MethodPost, "/orders", `{"reservation_id":1,"lines":[{"product_id":1,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/reservations/1", "", "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Reservation{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, domain.ReservationStatusConsumed, actual["data"].Status)
+
+	req, rr = createRequestTest(http.MethodDelete, "/reservations/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Order_Cancel_Restores_Stock(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":2}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/cancel", `{"reason":"customer request"}`, "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Order{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, domain.OrderStatusCancelled, actual["data"].Status)
+	assert.Equal(t, actual["data"].TotalPrice, actual["data"].Refunded)
+	assert.Len(t, actual["data"].History, 2)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity, after[0].Quantity)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/cancel", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Order_Return_Uses_Order_Tax(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	//11 units fall in the 17% tier
+	req, rr := createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":11}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/returns", `{"lines":[{"product_id":1,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/ship", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/returns", `{"lines":[{"product_id":1,"quantity":1}]}`, "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Order{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, domain.OrderStatusPartiallyReturned, actual["data"].Status)
+	//71.42 * 1.17
+	assert.Equal(t, 83.56, actual["data"].Refunded)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/returns", `{"lines":[{"product_id":1,"quantity":11}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-10, after[0].Quantity)
+}
+
+func Test_Order_Bundle_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	body := `{"name":"Gift basket","code_value":"BASKET","is_published":true,"pricing":"components","discount":0.1,
+		"components":[{"product_id":1,"quantity":2},{"product_id":2,"quantity":1}]}`
+	req, rr := createRequestTest(http.MethodPost, "/products/bundles", body, "")
+	r.ServeHTTP(rr, req)
+	bundle := map[string]domain.Product{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &bundle))
+	//Available as long as both components are
+	expected := p[0].Quantity / 2
+	if p[1].Quantity < expected {
+		expected = p[1].Quantity
+	}
+	assert.Equal(t, expected, bundle["data"].Quantity)
+
+	body = fmt.Sprintf(`{"lines":[{"product_id":%d,"quantity":3}]}`, bundle["data"].ID)
+	req, rr = createRequestTest(http.MethodPost, "/orders", body, "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Order{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	//(71.42 * 2 + 352.79) * 0.9 * 3 * 1.21
+	assert.Equal(t, 1619.22, actual["data"].TotalPrice)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-6, after[0].Quantity)
+	assert.Equal(t, p[1].Quantity-3, after[1].Quantity)
+
+	//Cancelling gives the units back to the components
+	req, rr = createRequestTest(http.MethodPost, fmt.Sprintf("/orders/%d/cancel", actual["data"].ID), "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	after, _ = loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity, after[0].Quantity)
+	assert.Equal(t, p[1].Quantity, after[1].Quantity)
+}
+
+func Test_PurchaseOrder_Receive_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/suppliers", `{"name":"Acme","products":[{"product_id":1,"supplier_code":"AC-1","cost":40}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders", `{"supplier_id":1,"lines":[{"product_id":1,"quantity":10}]}`, "")
+	r.ServeHTTP(rr, req)
+	created := map[string]domain.PurchaseOrder{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, 400.0, created["data"].Total)
+	assert.Equal(t, "AC-1", created["data"].Lines[0].SupplierCode)
+
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders/1/receive", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity+10, after[0].Quantity)
+	assert.Equal(t, 40.0, after[0].Cost)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/margins", "", "")
+	r.ServeHTTP(rr, req)
+	margins := map[string][]domain.Margin{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &margins))
+	assert.Equal(t, []domain.Margin{{ProductID: 1, Name: p[0].Name, Price: 71.42, Cost: 40, Margin: 31.42, MarginPercent: 43.99}}, margins["data"])
+
+	//Received orders can not be received again nor their supplier deleted
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders/1/receive", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/suppliers/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_PurchaseOrder_Receive_Fails(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/suppliers", `{"name":"Acme","products":[{"product_id":1,"supplier_code":"AC-1","cost":40}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders", `{"supplier_id":1,"lines":[{"product_id":1,"quantity":10}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//The product became a bundle after it was ordered, so it holds no stock
+	bundled, _ := loadProducts("./products_copy.json")
+	bundled[0].Components = []domain.BundleComponent{{ProductID: 2, Quantity: 1}}
+	assert.Nil(t, writeProducts("./products_copy.json", bundled))
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders/1/receive", "", "")
+	r.ServeHTTP(rr, req)
+	assert.NotEqual(t, http.StatusOK, rr.Code)
+
+	//The order is left open and is received once the product holds stock again
+	req, rr = createRequestTest(http.MethodGet, "/purchase_orders/1", "", "")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.PurchaseOrder{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, domain.PurchaseOrderStatusOpen, found["data"].Status)
+	assert.Nil(t, found["data"].ReceivedAt)
+
+	assert.Nil(t, writeProducts("./products_copy.json", p))
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders/1/receive", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity+10, after[0].Quantity)
+}
+
+func Test_PurchaseOrder_ReceiveCancel_Concurrent(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/suppliers", `{"name":"Acme","products":[{"product_id":1,"supplier_code":"AC-1","cost":40}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders", `{"supplier_id":1,"lines":[{"product_id":1,"quantity":10}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//An order is either received or cancelled, never both
+	paths := []string{"/purchase_orders/1/receive", "/purchase_orders/1/cancel"}
+	codes := make([]int, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			req, rr := createRequestTest(http.MethodPost, path, "", "")
+			r.ServeHTTP(rr, req)
+			codes[i] = rr.Code
+		}(i, path)
+	}
+	wg.Wait()
+	assert.ElementsMatch(t, []int{http.StatusOK, http.StatusConflict}, codes)
+
+	after, _ := loadProducts("./products_copy.json")
+	if codes[0] == http.StatusOK {
+		assert.Equal(t, p[0].Quantity+10, after[0].Quantity)
+	} else {
+		assert.Equal(t, p[0].Quantity, after[0].Quantity)
+	}
+}
+
+func Test_Currency_Delete_InUse(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	rates, err := os.ReadFile("./exchange_rates_copy.json")
+	assert.Nil(t, err)
+	defer func() {
+		restoreOrderFixtures(t, p)
+		assert.Nil(t, os.WriteFile("./exchange_rates_copy.json", rates, 0644))
+	}()
+
+	priced, _ := loadProducts("./products_copy.json")
+	priced[1].Currency = "ARS"
+	assert.Nil(t, writeProducts("./products_copy.json", priced))
+
+	r := createOrderServer()
+	req, rr := createRequestTest(http.MethodPost, "/suppliers", `{"name":"Acme","currency":"EUR","products":[{"product_id":1,"cost":40}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//Rates can not be deleted while products or suppliers are priced in them
+	for _, code := range []string{"ARS", "eur"} {
+		req, rr = createRequestTest(http.MethodDelete, "/exchange_rates/"+code, "", "")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	}
+	req, rr = createRequestTest(http.MethodDelete, "/exchange_rates/CHF", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	//Suppliers saved while the rate is deleted are either seen or turned down
+	var wg sync.WaitGroup
+	deleted := 0
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 3 {
+				req, rr := createRequestTest(http.MethodDelete, "/exchange_rates/BRL", "", "")
+				r.ServeHTTP(rr, req)
+				if rr.Code == http.StatusNoContent {
+					deleted++
+				}
+				return
+			}
+			body := fmt.Sprintf(`{"name":"Supplier %d","currency":"BRL","products":[{"product_id":1,"cost":40}]}`, i)
+			req, rr := createRequestTest(http.MethodPost, "/suppliers", body, "")
+			r.ServeHTTP(rr, req)
+		}(i)
+	}
+	wg.Wait()
+	suppliers, err := os.ReadFile("./suppliers_copy.json")
+	assert.Nil(t, err)
+	if deleted == 1 {
+		assert.NotContains(t, string(suppliers), `"BRL"`)
+	} else {
+		assert.Contains(t, string(suppliers), `"BRL"`)
+	}
+}
+
+func Test_Order_PackSizes_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer restoreOrderFixtures(t, p)
+
+	packed, _ := loadProducts("./products_copy.json")
+	packed[0].PackSizes = []domain.PackSize{{Unit: "case", Factor: 6, Price: 400}, {Unit: "box", Factor: 3}}
+	assert.Nil(t, writeProducts("./products_copy.json", packed))
+
+	r := createOrderServer()
+	body := `{"lines":[{"product_id":1,"quantity":1,"unit":"case"},{"product_id":1,"quantity":1,"unit":"box"},{"product_id":1,"quantity":2}]}`
+	req, rr := createRequestTest(http.MethodPost, "/orders", body, "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Order{}
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	//The case sells at its own price and the other 5 units at 71.42: (400 + 71.42 * 5) * 1.17
+	assert.Equal(t, 11, actual["data"].Lines[0].Quantity)
+	assert.Equal(t, 757.1, actual["data"].Lines[0].Price)
+	assert.Equal(t, 885.81, actual["data"].TotalPrice)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-11, after[0].Quantity)
+
+	//Suppliers sell cases, received as the units they hold
+	req, rr = createRequestTest(http.MethodPost, "/suppliers", `{"name":"Acme","products":[{"product_id":1,"unit":"case","cost":240}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders", `{"supplier_id":1,"lines":[{"product_id":1,"quantity":2}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/purchase_orders/1/receive", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	after, _ = loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity+1, after[0].Quantity)
+	assert.Equal(t, 40.0, after[0].Cost)
+
+	req, rr = createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":1,"unit":"pallet"}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	//Returned cases go back as the units they hold, up to the units shipped
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/ship", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/returns", `{"lines":[{"product_id":1,"quantity":2,"unit":"case"}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/orders/1/returns", `{"lines":[{"product_id":1,"quantity":1,"unit":"case"}]}`, "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, 6, actual["data"].Returns[0].Lines[0].Quantity)
+	after, _ = loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity+7, after[0].Quantity)
+}
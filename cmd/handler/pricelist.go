@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/pricelist"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type PriceList struct {
+	priceListService pricelist.Service
+}
+
+func NewPriceListHandler(s pricelist.Service) *PriceList {
+	return &PriceList{
+		priceListService: s,
+	}
+}
+
+func (h *PriceList) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		priceLists := h.priceListService.GetAll(c)
+		web.Success(c, http.StatusOK, priceLists)
+	}
+}
+
+func (h *PriceList) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		priceList, err := h.priceListService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, priceList)
+	}
+}
+
+func (h *PriceList) Save() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var priceListRequest domain.PriceList
+		if err := c.ShouldBindJSON(&priceListRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		priceList, err := h.priceListService.Save(c, priceListRequest)
+		if err != nil {
+			web.Failure(c, priceListErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, priceList)
+	}
+}
+
+func (h *PriceList) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var priceListRequest domain.PriceList
+		if err := c.ShouldBindJSON(&priceListRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		priceList, err := h.priceListService.Update(c, priceListRequest, id)
+		if err != nil {
+			web.Failure(c, priceListErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, priceList)
+	}
+}
+
+func (h *PriceList) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = h.priceListService.Delete(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// maps price list service errors to response status codes
+func priceListErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pricelist.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pricelist.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, pricelist.ErrCreatingPriceList), errors.Is(err, pricelist.ErrUpdatingPriceList):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
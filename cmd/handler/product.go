@@ -10,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hernan-hdiaz/go-web/internal/domain"
 	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
 	"github.com/hernan-hdiaz/go-web/pkg/web"
 )
 
@@ -19,13 +21,26 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+// CacheControl holds the Cache-Control headers sent with product reads, one
+// for lists and one for single products. Empty values send no header.
+type CacheControl struct {
+	List    string
+	Product string
+}
+
+// DefaultCacheControl lets clients keep product reads as long as they
+// revalidate them first
+var DefaultCacheControl = CacheControl{List: "no-cache", Product: "no-cache"}
+
 type Product struct {
 	productService product.Service
+	cache          CacheControl
 }
 
-func NewProductHandler(p product.Service) *Product {
+func NewProductHandler(p product.Service, cache CacheControl) *Product {
 	return &Product{
 		productService: p,
+		cache:          cache,
 	}
 }
 
@@ -37,27 +52,120 @@ func (p *Product) Get() gin.HandlerFunc {
 			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
 			return
 		}
-		//Search product by ID
-		product, err := p.productService.Get(c, id)
-		if err != nil {
-			web.Failure(c, http.StatusNotFound, err)
+		//Search product by ID, as it was at a given time when requested
+		var product domain.Product
+		var lastModified time.Time
+		if c.Query("at") != "" {
+			at, err := time.Parse(time.RFC3339, c.Query("at"))
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, ErrCanNotParse)
+				return
+			}
+			product, err = p.productService.At(c, id, at)
+			if err != nil {
+				web.Failure(c, http.StatusNotFound, err)
+				return
+			}
+		} else {
+			lastModified = p.productService.LastModified(c)
+			product, err = p.productService.Get(c, id)
+			if err != nil {
+				web.Failure(c, http.StatusNotFound, err)
+				return
+			}
+			//Bundles may be priced through exchange rates, kept apart from
+			//products
+			if product.IsBundle() {
+				lastModified = time.Time{}
+			}
+		}
+		//Convert price when a currency is requested, exchange rates change
+		//apart from products
+		if currency := c.Query("currency"); currency != "" {
+			converted, err := p.productService.InCurrency(c, []domain.Product{product}, currency)
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, err)
+				return
+			}
+			product = converted[0]
+			lastModified = time.Time{}
+		}
+		localizeOne(c, &product)
+		if c.Query("at") != "" {
+			web.Success(c, http.StatusOK, product)
 			return
 		}
-		//Return found product
-		web.Success(c, http.StatusOK, product)
+		//Return found product unless the caller's copy is current
+		c.Header("Cache-Control", p.cache.Product)
+		web.Conditional(c, product, func(body []byte) string {
+			return web.RepresentationETag(product.Version, body)
+		}, lastModified)
 	}
 }
 
 func (p *Product) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		products := p.productService.GetAll(c)
-		//Return products
-		web.Success(c, http.StatusOK, products)
+		var products []domain.Product
+		lastModified := p.productService.LastModified(c)
+		//Filter by category, tags and name when requested. Categories are kept
+		//apart from products, so filtered lists carry no Last-Modified.
+		if c.Query("category_id") != "" || c.Query("tags") != "" || c.Query("name") != "" {
+			lastModified = time.Time{}
+			filter := domain.ProductFilter{Name: c.Query("name"), Locales: web.Locales(c)}
+			if c.Query("category_id") != "" {
+				categoryID, err := strconv.Atoi(c.Query("category_id"))
+				if err != nil {
+					web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+					return
+				}
+				filter.CategoryID = categoryID
+			}
+			if c.Query("tags") != "" {
+				filter.Tags = strings.Split(c.Query("tags"), ",")
+			}
+			filtered, err := p.productService.Filter(c, filter)
+			if err != nil {
+				web.Failure(c, http.StatusNotFound, err)
+				return
+			}
+			products = filtered
+		} else {
+			products = p.productService.GetAll(c)
+		}
+		//Bundles may be priced through exchange rates, kept apart from products
+		for _, product := range products {
+			if product.IsBundle() {
+				lastModified = time.Time{}
+			}
+		}
+		//Convert prices when a currency is requested
+		if currency := c.Query("currency"); currency != "" {
+			converted, err := p.productService.InCurrency(c, products, currency)
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, err)
+				return
+			}
+			products = converted
+			lastModified = time.Time{}
+		}
+		localize(c, products)
+		//Return products unless the caller's copy is current
+		c.Header("Cache-Control", p.cache.List)
+		web.Conditional(c, products, web.WeakETag, lastModified)
 	}
 }
 
 func (p *Product) GetTotalPrice() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var warehouseID int
+		if c.Query("warehouse_id") != "" {
+			id, err := strconv.Atoi(c.Query("warehouse_id"))
+			if err != nil {
+				web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+				return
+			}
+			warehouseID = id
+		}
 		productList := c.Query("list")
 		productList, _ = strings.CutPrefix(productList, "[")
 		productList, _ = strings.CutSuffix(productList, "]")
@@ -72,7 +180,11 @@ func (p *Product) GetTotalPrice() gin.HandlerFunc {
 			}
 			convertedProductListIds = append(convertedProductListIds, productId)
 		}
-		completeProductList, totalPrice, err := p.productService.GetTotalPrice(c, convertedProductListIds)
+		completeProductList, totalPrice, err := p.productService.GetTotalPrice(c, convertedProductListIds, domain.PriceOptions{
+			Currency:      c.Query("currency"),
+			CustomerGroup: c.Query("customer_group"),
+			WarehouseID:   warehouseID,
+		})
 		if err != nil {
 			web.Failure(c, http.StatusBadRequest, err)
 			return
@@ -90,6 +202,37 @@ func (p *Product) GetTotalPrice() gin.HandlerFunc {
 	}
 }
 
+func (p *Product) Lookup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.Query("code")
+		if code == "" {
+			web.Failure(c, http.StatusBadRequest, ErrCanNotParse)
+			return
+		}
+		found, err := p.productService.Lookup(c, code)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		localizeOne(c, &found)
+		web.Success(c, http.StatusOK, found)
+	}
+}
+
+func (p *Product) LowStock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		products := p.productService.LowStock(c)
+		web.Success(c, http.StatusOK, products)
+	}
+}
+
+func (p *Product) Margins() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		margins := p.productService.Margins(c)
+		web.Success(c, http.StatusOK, margins)
+	}
+}
+
 func (p *Product) SearchByPriceGt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		priceGt, err := strconv.ParseFloat(c.Query("priceGt"), 64)
@@ -124,11 +267,22 @@ func (p *Product) Save() gin.HandlerFunc {
 		}
 
 		productRequest.ID, err = p.productService.Save(c, productRequest)
+		if errors.Is(err, gtin.ErrInvalidCode) || errors.Is(err, gtin.ErrCheckDigit) || errors.Is(err, product.ErrTranslation) {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
 		if err != nil {
 			web.Failure(c, http.StatusConflict, err)
 			return
 		}
-		web.Success(c, http.StatusCreated, productRequest)
+		//Return the product as saved, with its code value normalized
+		created, err := p.productService.Get(c, productRequest.ID)
+		if err != nil {
+			web.Failure(c, http.StatusInternalServerError, err)
+			return
+		}
+		c.Set(web.ProductIDKey, created.ID)
+		web.Tagged(c, http.StatusCreated, created.Version, created)
 	}
 }
 
@@ -155,12 +309,27 @@ func (p *Product) Update() gin.HandlerFunc {
 				return
 			}
 		}
-		productUpdated, err := p.productService.Update(c, productRequest, id)
+		//Only update the version the caller read, when told
+		productUpdated, err := p.productService.Update(c, productRequest, id, web.IfMatch(c))
+		if errors.Is(err, product.ErrVersionConflict) {
+			web.Failure(c, http.StatusPreconditionFailed, err)
+			return
+		}
+		if errors.Is(err, gtin.ErrInvalidCode) || errors.Is(err, gtin.ErrCheckDigit) || errors.Is(err, product.ErrTranslation) {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
 		if err != nil {
 			web.Failure(c, http.StatusNotFound, err)
 			return
 		}
-		web.Success(c, http.StatusCreated, productUpdated)
+		//Return the product as reads of it do, so both carry the same ETag
+		updated, err := p.productService.Get(c, productUpdated.ID)
+		if err != nil {
+			web.Failure(c, http.StatusInternalServerError, err)
+			return
+		}
+		web.Tagged(c, http.StatusCreated, updated.Version, updated)
 	}
 }
 
@@ -172,8 +341,61 @@ func (p *Product) Delete() gin.HandlerFunc {
 			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
 			return
 		}
-		//Search product by codeValue
-		err = p.productService.Delete(c, id)
+		//Only delete the version the caller read, when told
+		err = p.productService.Delete(c, id, web.IfMatch(c))
+		if errors.Is(err, product.ErrVersionConflict) {
+			web.Failure(c, http.StatusPreconditionFailed, err)
+			return
+		}
+		if errors.Is(err, product.ErrHasVariants) || errors.Is(err, product.ErrInBundle) {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+func (p *Product) Trash() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		products := p.productService.Trash(c)
+		web.Success(c, http.StatusOK, products)
+	}
+}
+
+func (p *Product) Restore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		restored, err := p.productService.Restore(c, id)
+		if errors.Is(err, product.ErrRestoreDependency) {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, restored)
+	}
+}
+
+func (p *Product) Purge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = p.productService.Purge(c, id)
 		if err != nil {
 			web.Failure(c, http.StatusNotFound, err)
 			return
@@ -181,3 +403,351 @@ func (p *Product) Delete() gin.HandlerFunc {
 		web.Success(c, http.StatusNoContent, nil)
 	}
 }
+
+func (p *Product) Revisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		revisions, err := p.productService.Revisions(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, revisions)
+	}
+}
+
+func (p *Product) Revision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID and revision number from path params
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		number, err := strconv.Atoi(c.Param("number"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		revision, err := p.productService.Revision(c, id, number)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, revision)
+	}
+}
+
+func (p *Product) Revert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID and revision number from path params
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		number, err := strconv.Atoi(c.Param("number"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		reverted, err := p.productService.Revert(c, id, number)
+		switch {
+		case errors.Is(err, product.ErrNotFound), errors.Is(err, product.ErrRevisionNotFound):
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		case err != nil:
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		web.Success(c, http.StatusOK, reverted)
+	}
+}
+
+func (p *Product) Movements() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		ledger, err := p.productService.Movements(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, ledger)
+	}
+}
+
+func (p *Product) AddMovement() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var movementRequest domain.MovementRequest
+		if err := c.ShouldBindJSON(&movementRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		movement, err := p.productService.RecordMovement(c, id, movementRequest)
+		if errors.Is(err, product.ErrNotFound) {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		web.Success(c, http.StatusCreated, movement)
+	}
+}
+
+func (p *Product) Stock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		stock, err := p.productService.Stock(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, stock)
+	}
+}
+
+func (p *Product) Transfer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var transferRequest domain.TransferRequest
+		if err := c.ShouldBindJSON(&transferRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		transferred, err := p.productService.Transfer(c, id, transferRequest)
+		if errors.Is(err, product.ErrNotFound) || errors.Is(err, warehouse.ErrNotFound) {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		web.Success(c, http.StatusOK, transferred)
+	}
+}
+
+func (p *Product) Lots() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		lots, err := p.productService.Lots(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, lots)
+	}
+}
+
+func (p *Product) ReceiveLot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var lotRequest domain.LotRequest
+		if err := c.ShouldBindJSON(&lotRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		//Parse given date
+		_, err = time.Parse("02/01/2006", lotRequest.Expiration)
+		//Check valid format
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		received, err := p.productService.ReceiveLot(c, id, lotRequest)
+		if errors.Is(err, product.ErrNotFound) || errors.Is(err, warehouse.ErrNotFound) {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		web.Success(c, http.StatusCreated, received)
+	}
+}
+
+func (p *Product) Variants() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		variants, err := p.productService.Variants(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		localize(c, variants)
+		web.Success(c, http.StatusOK, variants)
+	}
+}
+
+func (p *Product) CreateVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var variantRequest domain.VariantRequest
+		if err := c.ShouldBindJSON(&variantRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		//Parse given date
+		_, err = time.Parse("02/01/2006", variantRequest.Expiration)
+		//Check valid format
+		if err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		variant, err := p.productService.CreateVariant(c, id, variantRequest)
+		if errors.Is(err, product.ErrNotFound) {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		c.Set(web.ProductIDKey, variant.ID)
+		web.Success(c, http.StatusCreated, variant)
+	}
+}
+
+func (p *Product) CreateBundle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var bundleRequest domain.BundleRequest
+		if err := c.ShouldBindJSON(&bundleRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		bundle, err := p.productService.CreateBundle(c, bundleRequest)
+		if errors.Is(err, product.ErrNotFound) {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		c.Set(web.ProductIDKey, bundle.ID)
+		web.Success(c, http.StatusCreated, bundle)
+	}
+}
+
+func (p *Product) Transition() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var statusRequest domain.StatusRequest
+		if err := c.ShouldBindJSON(&statusRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := p.productService.Transition(c, id, statusRequest)
+		if err != nil {
+			web.Failure(c, publishingErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (p *Product) Schedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var scheduleRequest domain.ScheduleRequest
+		if err := c.ShouldBindJSON(&scheduleRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := p.productService.Schedule(c, id, scheduleRequest)
+		if err != nil {
+			web.Failure(c, publishingErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+// maps publishing errors to response status codes
+func publishingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, product.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, product.ErrTransition), errors.Is(err, product.ErrSchedule):
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// translates products to the locales the caller asked for
+func localize(c *gin.Context, products []domain.Product) {
+	locales := web.Locales(c)
+	c.Header("Vary", "Accept-Language")
+	for i := range products {
+		products[i].Localize(locales)
+	}
+}
+
+// translates a product to the locales the caller asked for, telling which
+// one was used
+func localizeOne(c *gin.Context, p *domain.Product) {
+	c.Header("Vary", "Accept-Language")
+	if locale := p.Localize(web.Locales(c)); locale != "" {
+		c.Header("Content-Language", locale)
+	}
+}
@@ -2,17 +2,33 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hernan-hdiaz/go-web/cmd/handler"
+	"github.com/hernan-hdiaz/go-web/internal/alert"
+	"github.com/hernan-hdiaz/go-web/internal/attachment"
+	"github.com/hernan-hdiaz/go-web/internal/audit"
+	"github.com/hernan-hdiaz/go-web/internal/category"
+	"github.com/hernan-hdiaz/go-web/internal/currency"
 	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/ledger"
+	"github.com/hernan-hdiaz/go-web/internal/pricelist"
 	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/reservation"
+	"github.com/hernan-hdiaz/go-web/internal/stocktake"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
 	"github.com/hernan-hdiaz/go-web/pkg/store"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +36,15 @@ type response struct {
 	Data interface{} `json:"data"`
 }
 
+func TestMain(m *testing.M) {
+	code := m.Run()
+	//Every product write is kept as a revision and every mutation is audited
+	_ = os.WriteFile("./revisions_copy.json", []byte("[]"), 0644)
+	_ = os.WriteFile("./audit_copy.json", []byte("[]"), 0644)
+	_ = os.Remove("./products_copy_last_id.json")
+	os.Exit(code)
+}
+
 func createServer(token string) *gin.Engine {
 
 	if token != "" {
@@ -29,21 +54,92 @@ func createServer(token string) *gin.Engine {
 		}
 	}
 
-	db := store.NewStore("./products_copy.json")
+	attachmentStorage := store.NewAttachmentStore("./attachments_copy.json", "./attachments_copy")
+	db := store.NewStore("./products_copy.json", attachmentStorage, store.NewRevisionStore("./revisions_copy.json"))
 	repo := product.NewRepository(db)
-	service := product.NewService(repo)
-	productHandler := handler.NewProductHandler(service)
+	rates := currency.NewService(currency.NewRepository(store.NewRateStore("./exchange_rates_copy.json")), "USD", repo)
+	prices := pricelist.NewService(pricelist.NewRepository(store.NewPriceListStore("./price_lists_copy.json")))
+	movements := ledger.NewService(ledger.NewRepository(store.NewMovementStore("./movements_copy.json")))
+	holds := reservation.NewRepository(store.NewReservationStore("./reservations_copy.json"))
+	warehouses := warehouse.NewService(warehouse.NewRepository(store.NewWarehouseStore("./warehouses_copy.json")), repo)
+	categories := category.NewService(category.NewRepository(store.NewCategoryStore("./categories_copy.json")), repo)
+	service := product.NewService(repo, rates, prices, holds, movements, alert.NewLogAlerter(), warehouses, categories)
+	productHandler := handler.NewProductHandler(service, handler.DefaultCacheControl)
+	warehouseHandler := handler.NewWarehouseHandler(warehouses)
+	stocktakes := stocktake.NewService(stocktake.NewRepository(store.NewStocktakeStore("./stocktakes_copy.json")), service, warehouses)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakes)
+	categoryHandler := handler.NewCategoryHandler(categories)
+	attachments := attachment.NewService(attachment.NewRepository(attachmentStorage), service, 1024)
+	attachmentHandler := handler.NewAttachmentHandler(attachments)
+	auditHandler := handler.NewAuditHandler(audit.NewService(audit.NewRepository(store.NewAuditStore("./audit_copy.json"))), service)
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
+	//Routes are not guarded here, callers with a known token are identified
+	credentials := web.Credentials{"alice-token": "alice", "warehouse-token": "warehouse-1"}
+	r.Use(func(c *gin.Context) {
+		if actor, ok := credentials[c.GetHeader("token")]; ok {
+			c.Set(web.ActorKey, actor)
+		}
+	})
+	r.Use(auditHandler.Record())
+	r.GET("/audit", auditHandler.Search())
+
+	wr := r.Group("/warehouses")
+	{
+		wr.GET("", warehouseHandler.GetAll())
+		wr.POST("", warehouseHandler.Save())
+		wr.DELETE(":id", warehouseHandler.Delete())
+	}
+
+	cr := r.Group("/categories")
+	{
+		cr.POST("", categoryHandler.Save())
+		cr.PUT(":id", categoryHandler.Update())
+		cr.DELETE(":id", categoryHandler.Delete())
+	}
+
+	sr := r.Group("/stocktakes")
+	{
+		sr.GET(":id", stocktakeHandler.Get())
+		sr.GET(":id/variances", stocktakeHandler.Variances())
+		sr.POST("", stocktakeHandler.Open())
+		sr.POST(":id/counts", stocktakeHandler.Count())
+		sr.POST(":id/commit", stocktakeHandler.Commit())
+		sr.DELETE(":id", stocktakeHandler.Cancel())
+	}
 
 	pr := r.Group("/products")
 	{
 		pr.GET("", productHandler.GetAll())
 		pr.GET(":id", productHandler.Get())
+		pr.GET("/consumer_price", productHandler.GetTotalPrice())
 		pr.GET("/search", productHandler.SearchByPriceGt())
+		pr.GET("/lookup", productHandler.Lookup())
+		pr.GET("/low-stock", productHandler.LowStock())
 		pr.POST("", productHandler.Save())
+		pr.POST("/bundles", productHandler.CreateBundle())
 		pr.DELETE(":id", productHandler.Delete())
+		pr.GET("/trash", productHandler.Trash())
+		pr.POST("/trash/:id/restore", productHandler.Restore())
+		pr.GET(":id/revisions", productHandler.Revisions())
+		pr.GET(":id/revisions/:number", productHandler.Revision())
+		pr.POST(":id/revisions/:number/revert", productHandler.Revert())
+		pr.DELETE("/trash/:id", productHandler.Purge())
 		pr.PUT(":id", productHandler.Update())
+		pr.GET(":id/movements", productHandler.Movements())
+		pr.POST(":id/movements", productHandler.AddMovement())
+		pr.GET(":id/stock", productHandler.Stock())
+		pr.POST(":id/transfers", productHandler.Transfer())
+		pr.GET(":id/lots", productHandler.Lots())
+		pr.POST(":id/lots", productHandler.ReceiveLot())
+		pr.GET(":id/variants", productHandler.Variants())
+		pr.POST(":id/variants", productHandler.CreateVariant())
+		pr.POST(":id/status", productHandler.Transition())
+		pr.PUT(":id/schedule", productHandler.Schedule())
+		pr.GET(":id/attachments", attachmentHandler.GetAll())
+		pr.GET(":id/attachments/:attachment_id", attachmentHandler.Download())
+		pr.POST(":id/attachments", attachmentHandler.Upload())
+		pr.DELETE(":id/attachments/:attachment_id", attachmentHandler.Delete())
 	}
 	return r
 }
@@ -79,6 +175,11 @@ func writeProducts(path string, list []domain.Product) error {
 	if err != nil {
 		return err
 	}
+	//Restoring the fixtures also forgets the ids handed out since
+	err = os.Remove(strings.TrimSuffix(path, ".json") + "_last_id.json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	return err
 }
 
@@ -131,9 +232,66 @@ func Test_GetOne_OK(t *testing.T) {
 	assert.Equal(t, expectd.Data, actual["data"])
 }
 
+func Test_GetOne_Currency_OK(t *testing.T) {
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodGet, "/products/1?currency=eur", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+
+	actual := map[string]domain.Product{}
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	err := json.Unmarshal(rr.Body.Bytes(), &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, 65.71, actual["data"].Price)
+	assert.Equal(t, "EUR", actual["data"].Currency)
+}
+
+func Test_GetOne_Currency_BadRequest(t *testing.T) {
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodGet, "/products/1?currency=XXX", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_GetTotalPrice_Currency_OK(t *testing.T) {
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodGet, "/products/consumer_price?list=[1,1]&currency=EUR", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+
+	actual := map[string]struct {
+		TotalPrice float64 `json:"total_price"`
+	}{}
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	err := json.Unmarshal(rr.Body.Bytes(), &actual)
+	assert.Nil(t, err)
+	//71.42 * 2 * 0.92 * 1.21
+	assert.Equal(t, 159.01, actual["data"].TotalPrice)
+}
+
+func Test_GetTotalPrice_CustomerGroup_OK(t *testing.T) {
+	r := createServer("my-secret-token")
+	list := "[" + strings.TrimSuffix(strings.Repeat("1,", 10), ",") + "]"
+	req, rr := createRequestTest(http.MethodGet, "/products/consumer_price?list="+list+"&customer_group=Wholesale", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+
+	actual := map[string]struct {
+		Products   []domain.Product `json:"products"`
+		TotalPrice float64          `json:"total_price"`
+	}{}
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	err := json.Unmarshal(rr.Body.Bytes(), &actual)
+	assert.Nil(t, err)
+	//10 units reach the 60.00 break: 60 * 10 * 1.21
+	assert.Equal(t, 60.0, actual["data"].Products[0].Price)
+	assert.Equal(t, 726.0, actual["data"].TotalPrice)
+}
+
 func Test_Post_OK(t *testing.T) {
 	var expectd = response{Data: domain.Product{
 		ID:          500,
+		Version:     1,
 		Name:        "Oil - Margarine",
 		Quantity:    439,
 		CodeValue:   "TEST45050",
@@ -153,12 +311,49 @@ func Test_Post_OK(t *testing.T) {
 	actual := map[string]domain.Product{}
 	_ = json.Unmarshal(rr.Body.Bytes(), &actual)
 	_ = writeProducts("./products_copy.json", p)
+	_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
 
 	assert.Equal(t, http.StatusCreated, rr.Code)
 	assert.Equal(t, expectd.Data, actual["data"])
 
 }
 
+func Test_Post_ServerFields(t *testing.T) {
+	r := createServer("my-secret-token")
+	p, err := loadProducts("./products_copy.json")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, writeProducts("./products_copy.json", p))
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	//Fields only the server sets are ignored on create
+	body := `{"name":"Forged","quantity":10,"code_value":"FORGED","is_published":true,"expiration":"15/12/2023","price":5,
+		"deleted_at":"2024-01-01T00:00:00Z","deleted_by":"mallory","purge_at":"2024-01-02T00:00:00Z",
+		"components":[{"product_id":1,"quantity":1}],"pricing":"components","discount":0.5,
+		"stock":[{"warehouse_id":1,"quantity":3},{"warehouse_id":2,"quantity":90}],
+		"lots":[{"code":"L-1","quantity":1,"expiration":"01/01/2030"}],"cost":1}`
+	req, rr := createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	created := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.False(t, created["data"].InTrash())
+	assert.Empty(t, created["data"].DeletedBy)
+	assert.Nil(t, created["data"].PurgeAt)
+	assert.False(t, created["data"].IsBundle())
+	assert.Empty(t, created["data"].Pricing)
+	assert.Zero(t, created["data"].Discount)
+	assert.Empty(t, created["data"].Stock)
+	assert.Equal(t, 10, created["data"].WarehouseQuantity(domain.DefaultWarehouseID))
+	assert.Empty(t, created["data"].Lots)
+	assert.Zero(t, created["data"].Cost)
+
+	req, rr = createRequestTest(http.MethodGet, fmt.Sprintf("/products/%d", created["data"].ID), "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
 func Test_Delete_OK(t *testing.T) {
 
 	r := createServer("my-secret-token")
@@ -179,6 +374,34 @@ func Test_Delete_OK(t *testing.T) {
 	assert.Nil(t, rr.Body.Bytes())
 }
 
+func Test_Purge_KeepsIDs(t *testing.T) {
+	r := createServer("my-secret-token")
+	p, err := loadProducts("./products_copy.json")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, writeProducts("./products_copy.json", p)) }()
+
+	create := func(code string) int {
+		body := fmt.Sprintf(`{"name":"Purged","quantity":1,"code_value":"%s","is_published":true,"expiration":"15/12/2023","price":1}`, code)
+		req, rr := createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		created := map[string]domain.Product{}
+		_ = json.Unmarshal(rr.Body.Bytes(), &created)
+		return created["data"].ID
+	}
+
+	first := create("PURGED1")
+	req, rr := createRequestTest(http.MethodDelete, fmt.Sprintf("/products/%d", first), "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, fmt.Sprintf("/products/trash/%d", first), "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	//The id of a purged product is never handed out again
+	assert.Equal(t, first+1, create("PURGED2"))
+}
+
 func Test_BadRequest(t *testing.T) {
 	r := createServer("my-secret-token")
 	test := []string{http.MethodDelete, http.MethodGet, http.MethodPut}
@@ -217,3 +440,598 @@ func Test_Post_Unauthorized(t *testing.T) {
 	r.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
+
+func Test_Movements_Reconcile_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/products/1/movements", `{"type":"write_off","quantity":-9,"reason":"damaged"}`, "warehouse-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPut, "/products/1", `{"quantity":500}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/products/1/movements", `{"type":"receipt","quantity":-1}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/movements", "", "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.StockLedger{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+
+	ledger := actual["data"]
+	assert.True(t, ledger.Reconciled)
+	assert.Equal(t, 500, ledger.LedgerBalance)
+	//Opening balance, write-off and the adjustment made by the update
+	assert.Len(t, ledger.Movements, 3)
+	assert.Equal(t, p[0].Quantity, ledger.Movements[0].Quantity)
+	assert.Equal(t, domain.MovementWriteOff, ledger.Movements[1].Type)
+	assert.Equal(t, p[0].Quantity-9, ledger.Movements[1].Balance)
+	assert.Equal(t, domain.MovementAdjustment, ledger.Movements[2].Type)
+	assert.Equal(t, 500-(p[0].Quantity-9), ledger.Movements[2].Quantity)
+}
+
+func Test_Movements_LedgerFailure(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	warehouses, err := os.ReadFile("./warehouses_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./warehouses_copy.json", warehouses, 0644)
+		_ = os.RemoveAll("./movements_copy.json")
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/warehouses", `{"code":"north","name":"North store"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//A ledger that can not be written leaves the stock as it was
+	assert.Nil(t, os.Remove("./movements_copy.json"))
+	assert.Nil(t, os.Mkdir("./movements_copy.json", 0755))
+	req, rr = createRequestTest(http.MethodPost, "/products/1/movements", `{"type":"write_off","quantity":-9,"reason":"damaged"}`, "warehouse-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/transfers", `{"from_warehouse_id":1,"to_warehouse_id":2,"quantity":4}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/lots", `{"code":"L-2","quantity":5,"expiration":"01/03/2031"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity, after[0].Quantity)
+	assert.Equal(t, p[0].Stock, after[0].Stock)
+	assert.Equal(t, p[0].Lots, after[0].Lots)
+	assert.Equal(t, p[0].Expiration, after[0].Expiration)
+
+	//Nor is a product created without its initial stock in the ledger
+	body := `{"name":"Unrecorded","quantity":10,"code_value":"UNRECORDED","is_published":true,"expiration":"15/12/2023","price":5}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	after, _ = loadProducts("./products_copy.json")
+	assert.Len(t, after, len(p))
+}
+
+func Test_LowStock_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	body := fmt.Sprintf(`{"reorder_point":%d,"reorder_quantity":100}`, p[0].Quantity-1)
+	req, rr := createRequestTest(http.MethodPut, "/products/1", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/products/1/movements", `{"type":"write_off","quantity":-1}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/low-stock", "", "")
+	r.ServeHTTP(rr, req)
+	actual := map[string][]domain.Product{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Len(t, actual["data"], 1)
+	assert.Equal(t, 1, actual["data"][0].ID)
+	assert.Equal(t, 100, actual["data"][0].ReorderQuantity)
+}
+
+func Test_Lots_FEFO_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	//Product 1 expires 15/12/2021, so its current units become the first lot out
+	req, rr := createRequestTest(http.MethodPost, "/products/1/lots", `{"code":"L-2","quantity":20,"expiration":"01/03/2024"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/lots", `{"code":"L-1","quantity":5,"expiration":"01/02/2024"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//Write off every initial unit and 2 more, taken from the lot expiring next
+	body := fmt.Sprintf(`{"type":"write_off","quantity":%d}`, -(p[0].Quantity + 2))
+	req, rr = createRequestTest(http.MethodPost, "/products/1/movements", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/lots", "", "")
+	r.ServeHTTP(rr, req)
+	lots := map[string][]domain.Lot{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &lots))
+	assert.Equal(t, []domain.Lot{
+		{Code: domain.InitialLotCode, Quantity: 0, Expiration: "15/12/2021"},
+		{Code: "L-1", Quantity: 3, Expiration: "01/02/2024"},
+		{Code: "L-2", Quantity: 20, Expiration: "01/03/2024"},
+	}, lots["data"])
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1", "", "")
+	r.ServeHTTP(rr, req)
+	actual := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, "01/02/2024", actual["data"].Expiration)
+	assert.Equal(t, 23, actual["data"].Quantity)
+}
+
+func Test_Variants_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/products/1/variants", `{"code_value":"VAR-S","quantity":5,"expiration":"01/01/2024","price":10,"attributes":{"size":"S"}}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	created := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, p[0].Name, created["data"].Name)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/variants", `{"code_value":"VAR-L","quantity":7,"expiration":"01/01/2024","price":14.5,"attributes":{"size":"L"}}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1", "", "")
+	r.ServeHTTP(rr, req)
+	parent := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &parent))
+	assert.Equal(t, &domain.VariantSummary{Count: 2, Quantity: 12, MinPrice: 10, MaxPrice: 14.5}, parent["data"].Variants)
+
+	//Renaming the parent renames its variants, which can not be renamed alone
+	req, rr = createRequestTest(http.MethodPut, "/products/1", `{"name":"Renamed"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodGet, "/products/1/variants", "", "")
+	r.ServeHTTP(rr, req)
+	variants := map[string][]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &variants))
+	assert.Len(t, variants["data"], 2)
+	assert.Equal(t, "Renamed", variants["data"][1].Name)
+	req, rr = createRequestTest(http.MethodPut, fmt.Sprintf("/products/%d", variants["data"][0].ID), `{"name":"Other"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, rr = createRequestTest(http.MethodDelete, "/products/1", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Translations_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPut, "/products/1", `{"description":"Vegetable spread","translations":{"ES":{"name":"Aceite - Margarina","description":"Untable vegetal"}}}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//The lang param wins over the header and regional locales fall back to their language
+	req, rr = createRequestTest(http.MethodGet, "/products/1?lang=es-AR", "", "")
+	req.Header.Set("Accept-Language", "en")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, "Aceite - Margarina", found["data"].Name)
+	assert.Equal(t, "Untable vegetal", found["data"].Description)
+	assert.Equal(t, "es", rr.Header().Get("Content-Language"))
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1", "", "")
+	req.Header.Set("Accept-Language", "fr, es;q=0.8")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, "Aceite - Margarina", found["data"].Name)
+
+	//Products without the locale keep their default name
+	req, rr = createRequestTest(http.MethodGet, "/products/1?lang=fr", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, "Oil - Margarine", found["data"].Name)
+	assert.Equal(t, "Vegetable spread", found["data"].Description)
+	assert.Empty(t, rr.Header().Get("Content-Language"))
+
+	//Names are searched in the chosen locale
+	req, rr = createRequestTest(http.MethodGet, "/products?name=margarina&lang=es", "", "")
+	r.ServeHTTP(rr, req)
+	listed := map[string][]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	assert.Len(t, listed["data"], 1)
+	assert.Equal(t, "Aceite - Margarina", listed["data"][0].Name)
+	req, rr = createRequestTest(http.MethodGet, "/products?name=margarina", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	assert.Len(t, listed["data"], 0)
+
+	req, rr = createRequestTest(http.MethodPut, "/products/1", `{"translations":{"not a locale":{"name":"x"}}}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func Test_Publishing_Workflow_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	r := createServer("my-secret-token")
+	//is_published only changes along with the status and is ignored on PUT
+	req, rr := createRequestTest(http.MethodPut, "/products/1", `{"name":"Renamed","is_published":false}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	ignored := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &ignored))
+	assert.Equal(t, "Renamed", ignored["data"].Name)
+	assert.True(t, ignored["data"].IsPublished)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/status", `{"status":"in_review"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/products/1/status", `{"status":"archived","reason":"out of season"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	updated := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.False(t, updated["data"].IsPublished)
+	assert.Equal(t, domain.ProductStatusArchived, updated["data"].Status)
+	assert.Len(t, updated["data"].StatusHistory, 1)
+	assert.Equal(t, domain.ProductStatusPublished, updated["data"].StatusHistory[0].From)
+	assert.Equal(t, "out of season", updated["data"].StatusHistory[0].Reason)
+
+	for _, status := range []string{"draft", "in_review"} {
+		req, rr = createRequestTest(http.MethodPost, "/products/1/status", fmt.Sprintf(`{"status":%q}`, status), "my-secret-token")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	req, rr = createRequestTest(http.MethodPut, "/products/1/schedule", `{"publish_at":"2024-01-02T00:00:00Z","unpublish_at":"2024-01-01T00:00:00Z"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	req, rr = createRequestTest(http.MethodPut, "/products/1/schedule", `{"publish_at":"2024-01-01T00:00:00Z","unpublish_at":"2999-01-01T00:00:00Z"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	//The scheduler publishes products whose publish time passed
+	rates := currency.NewService(currency.NewRepository(store.NewRateStore("./exchange_rates_copy.json")), "USD")
+	repo := product.NewRepository(store.NewStore("./products_copy.json", store.NewAttachmentStore("./attachments_copy.json", "./attachments_copy"), store.NewRevisionStore("./revisions_copy.json")))
+	prices := pricelist.NewService(pricelist.NewRepository(store.NewPriceListStore("./price_lists_copy.json")))
+	movements := ledger.NewService(ledger.NewRepository(store.NewMovementStore("./movements_copy.json")))
+	holds := reservation.NewRepository(store.NewReservationStore("./reservations_copy.json"))
+	warehouses := warehouse.NewService(warehouse.NewRepository(store.NewWarehouseStore("./warehouses_copy.json")), repo)
+	categories := category.NewService(category.NewRepository(store.NewCategoryStore("./categories_copy.json")), repo)
+	service := product.NewService(repo, rates, prices, holds, movements, alert.NewLogAlerter(), warehouses, categories)
+	assert.Equal(t, 1, service.PublishDue(context.Background()))
+	assert.Equal(t, 0, service.PublishDue(context.Background()))
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.True(t, updated["data"].IsPublished)
+	assert.Equal(t, domain.ProductStatusPublished, updated["data"].Status)
+	assert.Nil(t, updated["data"].PublishAt)
+	assert.NotNil(t, updated["data"].UnpublishAt)
+	history := updated["data"].StatusHistory
+	assert.Len(t, history, 4)
+	assert.Equal(t, product.SchedulerActor, history[len(history)-1].Actor)
+}
+
+func Test_Trash_Restore_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodDelete, "/products/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodGet, "/products/2", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/trash", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	trashed := map[string][]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &trashed))
+	var found domain.Product
+	for _, trashedProduct := range trashed["data"] {
+		if trashedProduct.ID == 2 {
+			found = trashedProduct
+		}
+	}
+	assert.NotNil(t, found.DeletedAt)
+	assert.Equal(t, product.TrashRetention, found.PurgeAt.Sub(*found.DeletedAt))
+
+	//Codes of trashed products are held until they are purged
+	body := `{"name":"Copy","quantity":1,"code_value":"M4637","expiration":"01/01/2024","price":10}`
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/products/trash/2/restore", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	restored := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &restored))
+	assert.Equal(t, p[1], restored["data"])
+	req, rr = createRequestTest(http.MethodPost, "/products/trash/2/restore", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, rr = createRequestTest(http.MethodDelete, "/products/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/products/trash/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func Test_Revisions_Revert_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.WriteFile("./revisions_copy.json", []byte("[]"), 0644)
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPut, "/products/3", `{"name":"Renamed","price":99}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPut, "/products/3", `{"description":"Boxed"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//The state before the first recorded change is kept as the first revision
+	req, rr = createRequestTest(http.MethodGet, "/products/3/revisions", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	revisions := map[string][]domain.Revision{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &revisions))
+	assert.Len(t, revisions["data"], 3)
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "name", From: json.RawMessage(fmt.Sprintf("%q", p[2].Name)), To: json.RawMessage(`"Renamed"`)},
+		{Field: "price", From: json.RawMessage(fmt.Sprint(p[2].Price)), To: json.RawMessage(`99`)},
+	}, revisions["data"][1].Changes)
+	assert.Equal(t, "description", revisions["data"][2].Changes[0].Field)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/3?at=2000-01-01T00:00:00Z", "", "")
+	r.ServeHTTP(rr, req)
+	found := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, p[2], found["data"])
+
+	req, rr = createRequestTest(http.MethodPost, "/products/3/revisions/1/revert", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	//Reverting writes a new version
+	assert.Equal(t, 3, found["data"].Version)
+	reverted := found["data"]
+	reverted.Version = p[2].Version
+	assert.Equal(t, p[2], reverted)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/3/revisions/4", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	revision := map[string]domain.Revision{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &revision))
+	assert.Len(t, revision["data"].Changes, 3)
+	req, rr = createRequestTest(http.MethodPost, "/products/3/revisions/9/revert", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func Test_IfMatch_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodGet, "/products/5", "", "")
+	r.ServeHTTP(rr, req)
+	etag := rr.Header().Get("ETag")
+	version, err := web.Version(etag)
+	assert.Nil(t, err)
+	assert.Equal(t, p[4].Version, version)
+
+	//The first editor wins, the second one read a version that is gone
+	req, rr = createRequestTest(http.MethodPut, "/products/5", `{"name":"First"}`, "my-secret-token")
+	req.Header.Set("If-Match", etag)
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	written := rr.Header().Get("ETag")
+	version, err = web.Version(written)
+	assert.Nil(t, err)
+	assert.Equal(t, p[4].Version+1, version)
+	req, rr = createRequestTest(http.MethodPut, "/products/5", `{"name":"Second"}`, "my-secret-token")
+	req.Header.Set("If-Match", etag)
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/products/5", "", "my-secret-token")
+	req.Header.Set("If-Match", etag)
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	//Weak and unknown tags never match
+	for _, header := range []string{"not an etag", "W/" + written, `"other"`} {
+		req, rr = createRequestTest(http.MethodPut, "/products/5", `{"name":"Second"}`, "my-secret-token")
+		req.Header.Set("If-Match", header)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, header)
+	}
+
+	//Reads carry the same ETag as the write, and any tag of a list may match
+	req, rr = createRequestTest(http.MethodGet, "/products/5", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, written, rr.Header().Get("ETag"))
+	found := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, "First", found["data"].Name)
+	req, rr = createRequestTest(http.MethodDelete, "/products/5", "", "my-secret-token")
+	req.Header.Set("If-Match", etag+`, W/"1", `+written)
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func Test_Conditional_Get_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+	}()
+
+	//Products written in the current second carry no Last-Modified yet
+	past := time.Now().Add(-time.Hour)
+	r := createServer("my-secret-token")
+	for _, path := range []string{"/products", "/products/5"} {
+		assert.Nil(t, os.Chtimes("./products_copy.json", past, past))
+		req, rr := createRequestTest(http.MethodGet, path, "", "")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+		etag, lastModified := rr.Header().Get("ETag"), rr.Header().Get("Last-Modified")
+		assert.NotEmpty(t, etag)
+		assert.NotEmpty(t, lastModified)
+
+		//Unchanged copies are not sent again
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-None-Match", etag)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-Modified-Since", lastModified)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		//If-None-Match wins over If-Modified-Since
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-None-Match", `W/"stale", "stale"`)
+		req.Header.Set("If-Modified-Since", lastModified)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		//A change to the product makes the cached copies stale
+		req, rr = createRequestTest(http.MethodPut, "/products/5", `{"price":99.5}`, "my-secret-token")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-None-Match", etag)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+		//Left out while the second of the change lasts, later on it moves
+		assert.NotEqual(t, lastModified, rr.Header().Get("Last-Modified"))
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		req.Header.Set("If-Modified-Since", lastModified)
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		_ = writeProducts("./products_copy.json", p)
+	}
+	assert.Nil(t, os.Chtimes("./products_copy.json", past, past))
+
+	//Filtered lists and bundles depend on more than products
+	req, rr := createRequestTest(http.MethodGet, "/products?name=a", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Last-Modified"))
+	body := `{"name":"Gift basket","code_value":"BASKET","pricing":"components","components":[{"product_id":1,"quantity":2}]}`
+	req, rr = createRequestTest(http.MethodPost, "/products/bundles", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	bundle := map[string]domain.Product{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &bundle))
+	assert.Nil(t, os.Chtimes("./products_copy.json", past, past))
+	for _, path := range []string{"/products", fmt.Sprintf("/products/%d", bundle["data"].ID)} {
+		req, rr = createRequestTest(http.MethodGet, path, "", "")
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Header().Get("Last-Modified"))
+	}
+	assert.Nil(t, writeProducts("./products_copy.json", p))
+	assert.Nil(t, os.Chtimes("./products_copy.json", past, past))
+
+	//Converted prices follow exchange rates, so they carry no Last-Modified
+	req, rr = createRequestTest(http.MethodGet, "/products/5?currency=USD", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("ETag"))
+	assert.Empty(t, rr.Header().Get("Last-Modified"))
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/purchase"
+	"github.com/hernan-hdiaz/go-web/internal/supplier"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type PurchaseOrder struct {
+	purchaseService purchase.Service
+}
+
+func NewPurchaseOrderHandler(s purchase.Service) *PurchaseOrder {
+	return &PurchaseOrder{
+		purchaseService: s,
+	}
+}
+
+func (h *PurchaseOrder) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orders := h.purchaseService.GetAll(c)
+		web.Success(c, http.StatusOK, orders)
+	}
+}
+
+func (h *PurchaseOrder) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		order, err := h.purchaseService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, order)
+	}
+}
+
+func (h *PurchaseOrder) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var purchaseOrderRequest domain.PurchaseOrderRequest
+		if err := c.ShouldBindJSON(&purchaseOrderRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		created, err := h.purchaseService.Create(c, purchaseOrderRequest)
+		if err != nil {
+			web.Failure(c, purchaseErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *PurchaseOrder) Receive() gin.HandlerFunc {
+	return h.close(h.purchaseService.Receive)
+}
+
+func (h *PurchaseOrder) Cancel() gin.HandlerFunc {
+	return h.close(h.purchaseService.Cancel)
+}
+
+// builds a handler closing a purchase order
+func (h *PurchaseOrder) close(close func(ctx context.Context, id int) (domain.PurchaseOrder, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		closed, err := close(c, id)
+		if err != nil {
+			web.Failure(c, purchaseErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, closed)
+	}
+}
+
+// maps purchase order service errors to response status codes
+func purchaseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, purchase.ErrNotFound), errors.Is(err, supplier.ErrNotFound), errors.Is(err, warehouse.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, purchase.ErrNotOpen):
+		return http.StatusConflict
+	case errors.Is(err, purchase.ErrCreatingPurchaseOrder), errors.Is(err, purchase.ErrUpdatingPurchaseOrder):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/reservation"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Reservation struct {
+	reservationService reservation.Service
+}
+
+func NewReservationHandler(s reservation.Service) *Reservation {
+	return &Reservation{
+		reservationService: s,
+	}
+}
+
+func (h *Reservation) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reservations := h.reservationService.GetAll(c)
+		web.Success(c, http.StatusOK, reservations)
+	}
+}
+
+func (h *Reservation) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		reservation, err := h.reservationService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, reservation)
+	}
+}
+
+func (h *Reservation) Create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reservationRequest domain.ReservationRequest
+		if err := c.ShouldBindJSON(&reservationRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		createdReservation, err := h.reservationService.Create(c, reservationRequest)
+		if errors.Is(err, reservation.ErrCreatingReservation) {
+			web.Failure(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, err)
+			return
+		}
+		web.Success(c, http.StatusCreated, createdReservation)
+	}
+}
+
+func (h *Reservation) Cancel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		cancelled, err := h.reservationService.Cancel(c, id)
+		if errors.Is(err, reservation.ErrNotActive) {
+			web.Failure(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, cancelled)
+	}
+}
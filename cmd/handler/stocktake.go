@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/stocktake"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Stocktake struct {
+	stocktakeService stocktake.Service
+}
+
+func NewStocktakeHandler(s stocktake.Service) *Stocktake {
+	return &Stocktake{
+		stocktakeService: s,
+	}
+}
+
+func (h *Stocktake) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakes := h.stocktakeService.GetAll(c)
+		web.Success(c, http.StatusOK, stocktakes)
+	}
+}
+
+func (h *Stocktake) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		stocktake, err := h.stocktakeService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, stocktake)
+	}
+}
+
+func (h *Stocktake) Open() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var stocktakeRequest domain.StocktakeRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&stocktakeRequest); err != nil {
+				web.Failure(c, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+		opened, err := h.stocktakeService.Open(c, stocktakeRequest)
+		if err != nil {
+			web.Failure(c, stocktakeErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, opened)
+	}
+}
+
+func (h *Stocktake) Count() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var countRequest domain.CountRequest
+		if err := c.ShouldBindJSON(&countRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		counted, err := h.stocktakeService.Count(c, id, countRequest)
+		if err != nil {
+			web.Failure(c, stocktakeErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, counted)
+	}
+}
+
+func (h *Stocktake) Variances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		variances, err := h.stocktakeService.Variances(c, id)
+		if err != nil {
+			web.Failure(c, stocktakeErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, variances)
+	}
+}
+
+func (h *Stocktake) Commit() gin.HandlerFunc {
+	return h.close(h.stocktakeService.Commit)
+}
+
+func (h *Stocktake) Cancel() gin.HandlerFunc {
+	return h.close(h.stocktakeService.Cancel)
+}
+
+// builds a handler closing a stocktake session
+func (h *Stocktake) close(close func(ctx context.Context, id int) (domain.Stocktake, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		closed, err := close(c, id)
+		if err != nil {
+			web.Failure(c, stocktakeErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, closed)
+	}
+}
+
+// maps stocktake service errors to response status codes
+func stocktakeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, stocktake.ErrNotFound), errors.Is(err, product.ErrNotFound), errors.Is(err, warehouse.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, stocktake.ErrNotOpen):
+		return http.StatusConflict
+	case errors.Is(err, stocktake.ErrCreatingStocktake), errors.Is(err, stocktake.ErrUpdatingStocktake):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Stocktake_Commit_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./stocktakes_copy.json", []byte("[]"), 0644)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/stocktakes", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//Counts come in two batches, the second one recounting product 1
+	body := fmt.Sprintf(`{"counts":[{"product_id":1,"counted":%d},{"product_id":2,"counted":%d}]}`, p[0].Quantity, p[1].Quantity+4)
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/counts", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body = fmt.Sprintf(`{"counts":[{"product_id":1,"counted":%d}]}`, p[0].Quantity-3)
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/counts", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/stocktakes/1/variances", "", "")
+	r.ServeHTTP(rr, req)
+	variances := map[string][]domain.StockVariance{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &variances))
+	assert.Equal(t, []domain.StockVariance{
+		{ProductID: 1, Name: p[0].Name, Expected: p[0].Quantity, Counted: p[0].Quantity - 3, Variance: -3},
+		{ProductID: 2, Name: p[1].Name, Expected: p[1].Quantity, Counted: p[1].Quantity + 4, Variance: 4},
+	}, variances["data"])
+
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/commit", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/2/movements", "", "")
+	r.ServeHTTP(rr, req)
+	ledger := map[string]domain.StockLedger{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &ledger))
+	assert.Equal(t, p[1].Quantity+4, ledger["data"].Quantity)
+	assert.True(t, ledger["data"].Reconciled)
+	last := ledger["data"].Movements[len(ledger["data"].Movements)-1]
+	assert.Equal(t, "stocktake 1", last.Reference)
+	assert.Equal(t, 4, last.Quantity)
+
+	//A committed session takes no more counts
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/counts", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Stocktake_Commit_AfterSale(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		restoreOrderFixtures(t, p)
+		_ = os.WriteFile("./stocktakes_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/stocktakes", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	body := fmt.Sprintf(`{"counts":[{"product_id":1,"counted":%d}]}`, p[0].Quantity-2)
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/counts", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	//A unit sold after counting is kept sold by the commit
+	req, rr = createRequestTest(http.MethodPost, "/orders", `{"lines":[{"product_id":1,"quantity":1}]}`, "")
+	createOrderServer().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/stocktakes/1/variances", "", "")
+	r.ServeHTTP(rr, req)
+	variances := map[string][]domain.StockVariance{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &variances))
+	assert.Equal(t, []domain.StockVariance{
+		{ProductID: 1, Name: p[0].Name, Expected: p[0].Quantity, Counted: p[0].Quantity - 2, Variance: -2},
+	}, variances["data"])
+
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/commit", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	after, _ := loadProducts("./products_copy.json")
+	assert.Equal(t, p[0].Quantity-3, after[0].Quantity)
+}
+
+func Test_Stocktake_Concurrent(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./stocktakes_copy.json", []byte("[]"), 0644)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/stocktakes", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	body := fmt.Sprintf(`{"counts":[{"product_id":1,"counted":%d}]}`, p[0].Quantity+2)
+	req, rr = createRequestTest(http.MethodPost, "/stocktakes/1/counts", body, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	//Counts can not reopen a session, so it is committed or cancelled once
+	type call struct{ method, path, body string }
+	calls := []call{
+		{http.MethodPost, "/stocktakes/1/commit", ""},
+		{http.MethodPost, "/stocktakes/1/counts", body},
+		{http.MethodPost, "/stocktakes/1/commit", ""},
+		{http.MethodPost, "/stocktakes/1/counts", body},
+		{http.MethodDelete, "/stocktakes/1", ""},
+		{http.MethodPost, "/stocktakes/1/commit", ""},
+	}
+	codes := make([]int, len(calls))
+	var wg sync.WaitGroup
+	for i, c := range calls {
+		wg.Add(1)
+		go func(i int, c call) {
+			defer wg.Done()
+			req, rr := createRequestTest(c.method, c.path, c.body, "my-secret-token")
+			r.ServeHTTP(rr, req)
+			codes[i] = rr.Code
+		}(i, c)
+	}
+	wg.Wait()
+	closed := 0
+	for i, c := range calls {
+		if c.path != "/stocktakes/1/counts" && codes[i] == http.StatusOK {
+			closed++
+		}
+	}
+	assert.Equal(t, 1, closed)
+
+	req, rr = createRequestTest(http.MethodGet, "/stocktakes/1", "", "")
+	r.ServeHTTP(rr, req)
+	stocktake := map[string]domain.Stocktake{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &stocktake))
+	after, _ := loadProducts("./products_copy.json")
+	if stocktake["data"].Status == domain.StocktakeStatusCommitted {
+		assert.Equal(t, p[0].Quantity+2, after[0].Quantity)
+	} else {
+		assert.Equal(t, domain.StocktakeStatusCancelled, stocktake["data"].Status)
+		assert.Equal(t, p[0].Quantity, after[0].Quantity)
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/supplier"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Supplier struct {
+	supplierService supplier.Service
+}
+
+func NewSupplierHandler(s supplier.Service) *Supplier {
+	return &Supplier{
+		supplierService: s,
+	}
+}
+
+func (h *Supplier) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		suppliers := h.supplierService.GetAll(c)
+		web.Success(c, http.StatusOK, suppliers)
+	}
+}
+
+func (h *Supplier) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		found, err := h.supplierService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, found)
+	}
+}
+
+func (h *Supplier) Save() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var supplierRequest domain.Supplier
+		if err := c.ShouldBindJSON(&supplierRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		created, err := h.supplierService.Save(c, supplierRequest)
+		if err != nil {
+			web.Failure(c, supplierErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *Supplier) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var supplierRequest domain.Supplier
+		if err := c.ShouldBindJSON(&supplierRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := h.supplierService.Update(c, supplierRequest, id)
+		if err != nil {
+			web.Failure(c, supplierErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Supplier) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = h.supplierService.Delete(c, id)
+		if err != nil {
+			web.Failure(c, supplierErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// maps supplier service errors to response status codes
+func supplierErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, supplier.ErrNotFound), errors.Is(err, product.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, supplier.ErrInUse):
+		return http.StatusConflict
+	case errors.Is(err, supplier.ErrCreatingSupplier), errors.Is(err, supplier.ErrUpdatingSupplier):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Warehouse struct {
+	warehouseService warehouse.Service
+}
+
+func NewWarehouseHandler(s warehouse.Service) *Warehouse {
+	return &Warehouse{
+		warehouseService: s,
+	}
+}
+
+func (h *Warehouse) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		warehouses := h.warehouseService.GetAll(c)
+		web.Success(c, http.StatusOK, warehouses)
+	}
+}
+
+func (h *Warehouse) Get() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		found, err := h.warehouseService.Get(c, id)
+		if err != nil {
+			web.Failure(c, http.StatusNotFound, err)
+			return
+		}
+		web.Success(c, http.StatusOK, found)
+	}
+}
+
+func (h *Warehouse) Save() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var warehouseRequest domain.Warehouse
+		if err := c.ShouldBindJSON(&warehouseRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		created, err := h.warehouseService.Save(c, warehouseRequest)
+		if err != nil {
+			web.Failure(c, warehouseErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusCreated, created)
+	}
+}
+
+func (h *Warehouse) Update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		var warehouseRequest domain.Warehouse
+		if err := c.ShouldBindJSON(&warehouseRequest); err != nil {
+			web.Failure(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		updated, err := h.warehouseService.Update(c, warehouseRequest, id)
+		if err != nil {
+			web.Failure(c, warehouseErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusOK, updated)
+	}
+}
+
+func (h *Warehouse) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//Get ID from path param
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Failure(c, http.StatusBadRequest, ErrInvalidID)
+			return
+		}
+		err = h.warehouseService.Delete(c, id)
+		if err != nil {
+			web.Failure(c, warehouseErrorStatus(err), err)
+			return
+		}
+		web.Success(c, http.StatusNoContent, nil)
+	}
+}
+
+// maps warehouse service errors to response status codes
+func warehouseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, warehouse.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, warehouse.ErrAlreadyExists), errors.Is(err, warehouse.ErrInUse), errors.Is(err, warehouse.ErrDefaultWarehouse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
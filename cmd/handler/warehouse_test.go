@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Warehouse_Transfer_OK(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	warehouses, err := os.ReadFile("./warehouses_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./warehouses_copy.json", warehouses, 0644)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/warehouses", `{"code":"north","name":"North store"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	req, rr = createRequestTest(http.MethodPost, "/products/1/transfers", `{"from_warehouse_id":1,"to_warehouse_id":2,"quantity":10}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	transferred := map[string]domain.Product{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &transferred))
+	//Quantity stays the total of every warehouse
+	assert.Equal(t, p[0].Quantity, transferred["data"].Quantity)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/stock", "", "")
+	r.ServeHTTP(rr, req)
+	stock := map[string][]domain.WarehouseStock{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &stock))
+	assert.Equal(t, []domain.WarehouseStock{{WarehouseID: 1, Quantity: p[0].Quantity - 10}, {WarehouseID: 2, Quantity: 10}}, stock["data"])
+
+	list := strings.TrimSuffix(strings.Repeat("1,", 10), ",")
+	req, rr = createRequestTest(http.MethodGet, "/products/consumer_price?warehouse_id=2&list=["+list+"]", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/consumer_price?warehouse_id=2&list=["+list+",1]", "", "")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, rr = createRequestTest(http.MethodDelete, "/warehouses/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	//Products in the trash keep their warehouses in use until purged
+	req, rr = createRequestTest(http.MethodDelete, "/products/1", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	req, rr = createRequestTest(http.MethodDelete, "/warehouses/2", "", "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func Test_Warehouse_Transfer_KeepsLots(t *testing.T) {
+	p, err := loadProducts("./products_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	warehouses, err := os.ReadFile("./warehouses_copy.json")
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = writeProducts("./products_copy.json", p)
+		_ = os.WriteFile("./warehouses_copy.json", warehouses, 0644)
+		_ = os.WriteFile("./movements_copy.json", []byte("[]"), 0644)
+	}()
+
+	r := createServer("my-secret-token")
+	req, rr := createRequestTest(http.MethodPost, "/warehouses", `{"code":"north","name":"North store"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	req, rr = createRequestTest(http.MethodPost, "/products/1/lots", `{"code":"L-2","quantity":5,"expiration":"01/03/2031"}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	//Moving units between warehouses does not move them between lots
+	req, rr = createRequestTest(http.MethodPost, "/products/1/transfers", `{"from_warehouse_id":1,"to_warehouse_id":2,"quantity":4}`, "my-secret-token")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, rr = createRequestTest(http.MethodGet, "/products/1/lots", "", "")
+	r.ServeHTTP(rr, req)
+	lots := map[string][]domain.Lot{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &lots))
+	assert.Equal(t, []domain.Lot{
+		{Code: domain.InitialLotCode, Quantity: p[0].Quantity, Expiration: p[0].Expiration},
+		{Code: "L-2", Quantity: 5, Expiration: "01/03/2031"},
+	}, lots["data"])
+}
@@ -1,13 +1,30 @@
 package main
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hernan-hdiaz/go-web/cmd/handler"
+	"github.com/hernan-hdiaz/go-web/internal/alert"
+	"github.com/hernan-hdiaz/go-web/internal/attachment"
+	"github.com/hernan-hdiaz/go-web/internal/audit"
+	"github.com/hernan-hdiaz/go-web/internal/cart"
+	"github.com/hernan-hdiaz/go-web/internal/category"
+	"github.com/hernan-hdiaz/go-web/internal/currency"
 	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/ledger"
+	"github.com/hernan-hdiaz/go-web/internal/order"
+	"github.com/hernan-hdiaz/go-web/internal/pricelist"
 	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/purchase"
+	"github.com/hernan-hdiaz/go-web/internal/reservation"
+	"github.com/hernan-hdiaz/go-web/internal/stocktake"
+	"github.com/hernan-hdiaz/go-web/internal/supplier"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
 	"github.com/hernan-hdiaz/go-web/pkg/store"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
 	"github.com/joho/godotenv"
 )
 
@@ -20,10 +37,106 @@ func main() {
 		panic("Error loading .env file: " + err.Error())
 	}
 
-	storage := store.NewStore("./products.json")
+	priceListStorage := store.NewPriceListStore("./price_lists.json")
+	priceListRepo := pricelist.NewRepository(priceListStorage)
+	priceListService := pricelist.NewService(priceListRepo)
+	priceListHandler := handler.NewPriceListHandler(priceListService)
+
+	reservationStorage := store.NewReservationStore("./reservations.json")
+	reservationRepo := reservation.NewRepository(reservationStorage)
+
+	movementStorage := store.NewMovementStore("./movements.json")
+	movementRepo := ledger.NewRepository(movementStorage)
+	movementService := ledger.NewService(movementRepo)
+
+	alerter := alert.NewLogAlerter()
+	if url := os.Getenv("REORDER_WEBHOOK_URL"); url != "" {
+		alerter = alert.NewMultiAlerter(alerter, alert.NewWebhookAlerter(url))
+	}
+
+	attachmentStorage := store.NewAttachmentStore("./attachments.json", "./attachments")
+	revisionStorage := store.NewRevisionStore("./revisions.json")
+	storage := store.NewStore("./products.json", attachmentStorage, revisionStorage)
 	repo := product.NewRepository(storage)
-	service := product.NewService(repo)
-	handler := handler.NewProductHandler(service)
+
+	supplierStorage := store.NewSupplierStore("./suppliers.json")
+	supplierRepo := supplier.NewRepository(supplierStorage)
+
+	base := os.Getenv("BASE_CURRENCY")
+	if base == "" {
+		base = currency.DefaultBase
+	}
+	if !currency.IsCode(currency.Normalize(base)) {
+		panic("Error reading BASE_CURRENCY: " + currency.ErrInvalidCurrency.Error())
+	}
+	rateStorage := store.NewRateStore("./exchange_rates.json")
+	rateRepo := currency.NewRepository(rateStorage)
+	rateService := currency.NewService(rateRepo, base, repo, supplierRepo)
+	rateHandler := handler.NewCurrencyHandler(rateService)
+
+	warehouseStorage := store.NewWarehouseStore("./warehouses.json")
+	warehouseRepo := warehouse.NewRepository(warehouseStorage)
+	warehouseService := warehouse.NewService(warehouseRepo, repo)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService)
+
+	categoryStorage := store.NewCategoryStore("./categories.json")
+	categoryRepo := category.NewRepository(categoryStorage)
+	categoryService := category.NewService(categoryRepo, repo)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+
+	service := product.NewService(repo, rateService, priceListService, reservationRepo, movementService, alerter, warehouseService, categoryService)
+
+	go PublishScheduled(service, time.Minute)
+	go PurgeTrash(service, time.Hour)
+
+	reservationService := reservation.NewService(reservationRepo, service)
+	reservationHandler := handler.NewReservationHandler(reservationService)
+	go ExpireReservations(reservationService, time.Minute)
+
+	orderStorage := store.NewOrderStore("./orders.json")
+	orderRepo := order.NewRepository(orderStorage)
+	orderService := order.NewService(orderRepo, service, reservationService)
+	orderHandler := handler.NewOrderHandler(orderService)
+
+	cartStorage := store.NewCartStore("./carts.json")
+	cartRepo := cart.NewRepository(cartStorage)
+	cartService := cart.NewService(cartRepo, service, orderService)
+	cartHandler := handler.NewCartHandler(cartService)
+
+	stocktakeStorage := store.NewStocktakeStore("./stocktakes.json")
+	stocktakeRepo := stocktake.NewRepository(stocktakeStorage)
+	stocktakeService := stocktake.NewService(stocktakeRepo, service, warehouseService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+
+	purchaseStorage := store.NewPurchaseOrderStore("./purchase_orders.json")
+	purchaseRepo := purchase.NewRepository(purchaseStorage)
+	supplierService := supplier.NewService(supplierRepo, service, rateService, purchaseRepo)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseService := purchase.NewService(purchaseRepo, supplierService, service, warehouseService)
+	purchaseHandler := handler.NewPurchaseOrderHandler(purchaseService)
+
+	attachmentRepo := attachment.NewRepository(attachmentStorage)
+	attachmentService := attachment.NewService(attachmentRepo, service, attachment.DefaultMaxSize)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+
+	auditStorage := store.NewAuditStore("./audit.json")
+	auditService := audit.NewService(audit.NewRepository(auditStorage))
+	auditHandler := handler.NewAuditHandler(auditService, service)
+
+	cache := handler.DefaultCacheControl
+	if value := os.Getenv("PRODUCTS_CACHE_CONTROL"); value != "" {
+		cache.List = value
+	}
+	if value := os.Getenv("PRODUCT_CACHE_CONTROL"); value != "" {
+		cache.Product = value
+	}
+
+	handler := handler.NewProductHandler(service, cache)
+
+	credentials, err := Credentials()
+	if err != nil {
+		panic("Error reading API tokens: " + err.Error())
+	}
 
 	router := gin.Default()
 
@@ -34,23 +147,130 @@ func main() {
 	router.GET("/products/:id", handler.Get())
 	router.GET("/products/consumer_price", handler.GetTotalPrice())
 	router.GET("/products/search", handler.SearchByPriceGt())
-	router.Use(TokenAuthMiddleware())
+	router.GET("/products/lookup", handler.Lookup())
+	router.GET("/products/low-stock", handler.LowStock())
+	router.GET("/products/:id/movements", handler.Movements())
+	router.GET("/products/:id/stock", handler.Stock())
+	router.GET("/products/:id/lots", handler.Lots())
+	router.GET("/products/:id/variants", handler.Variants())
+	router.GET("/products/:id/attachments", attachmentHandler.GetAll())
+	router.GET("/products/:id/attachments/:attachment_id", attachmentHandler.Download())
+	router.GET("/exchange_rates", rateHandler.GetAll())
+	router.GET("/price_lists", priceListHandler.GetAll())
+	router.GET("/price_lists/:id", priceListHandler.Get())
+	router.GET("/warehouses", warehouseHandler.GetAll())
+	router.GET("/warehouses/:id", warehouseHandler.Get())
+	router.GET("/categories", categoryHandler.GetAll())
+	router.GET("/categories/:id", categoryHandler.Get())
+	router.Use(web.Authenticate(credentials))
+	router.Use(auditHandler.Record())
+	router.GET("/audit", auditHandler.Search())
 	router.POST("/products", handler.Save())
+	router.POST("/products/bundles", handler.CreateBundle())
 	router.PUT("/products/:id", handler.Update())
 	router.DELETE("/products/:id", handler.Delete())
+	router.GET("/products/trash", handler.Trash())
+	router.POST("/products/trash/:id/restore", handler.Restore())
+	router.GET("/products/:id/revisions", handler.Revisions())
+	router.GET("/products/:id/revisions/:number", handler.Revision())
+	router.POST("/products/:id/revisions/:number/revert", handler.Revert())
+	router.DELETE("/products/trash/:id", handler.Purge())
+	router.POST("/products/:id/movements", handler.AddMovement())
+	router.POST("/products/:id/transfers", handler.Transfer())
+	router.POST("/products/:id/lots", handler.ReceiveLot())
+	router.POST("/products/:id/variants", handler.CreateVariant())
+	router.POST("/products/:id/status", handler.Transition())
+	router.PUT("/products/:id/schedule", handler.Schedule())
+	router.POST("/products/:id/attachments", attachmentHandler.Upload())
+	router.DELETE("/products/:id/attachments/:attachment_id", attachmentHandler.Delete())
+	router.PUT("/exchange_rates/:currency", rateHandler.Save())
+	router.DELETE("/exchange_rates/:currency", rateHandler.Delete())
+	router.POST("/price_lists", priceListHandler.Save())
+	router.PUT("/price_lists/:id", priceListHandler.Update())
+	router.DELETE("/price_lists/:id", priceListHandler.Delete())
+	router.POST("/warehouses", warehouseHandler.Save())
+	router.PUT("/warehouses/:id", warehouseHandler.Update())
+	router.DELETE("/warehouses/:id", warehouseHandler.Delete())
+	router.POST("/categories", categoryHandler.Save())
+	router.PUT("/categories/:id", categoryHandler.Update())
+	router.DELETE("/categories/:id", categoryHandler.Delete())
+	router.GET("/orders", orderHandler.GetAll())
+	router.GET("/orders/:id", orderHandler.Get())
+	router.POST("/orders", orderHandler.Create())
+	router.POST("/orders/:id/ship", orderHandler.Ship())
+	router.POST("/orders/:id/cancel", orderHandler.Cancel())
+	router.POST("/orders/:id/returns", orderHandler.Return())
+	router.GET("/reservations", reservationHandler.GetAll())
+	router.GET("/reservations/:id", reservationHandler.Get())
+	router.POST("/reservations", reservationHandler.Create())
+	router.DELETE("/reservations/:id", reservationHandler.Cancel())
+	router.GET("/carts/:id", cartHandler.Get())
+	router.GET("/carts/:id/price", cartHandler.Price())
+	router.POST("/carts", cartHandler.Create())
+	router.POST("/carts/:id/lines", cartHandler.AddLine())
+	router.PUT("/carts/:id/lines/:product_id", cartHandler.UpdateLine())
+	router.PUT("/carts/:id/lines/:product_id/:unit", cartHandler.UpdateLine())
+	router.DELETE("/carts/:id/lines/:product_id", cartHandler.RemoveLine())
+	router.DELETE("/carts/:id/lines/:product_id/:unit", cartHandler.RemoveLine())
+	router.POST("/carts/:id/checkout", cartHandler.Checkout())
+	router.GET("/stocktakes", stocktakeHandler.GetAll())
+	router.GET("/stocktakes/:id", stocktakeHandler.Get())
+	router.GET("/stocktakes/:id/variances", stocktakeHandler.Variances())
+	router.POST("/stocktakes", stocktakeHandler.Open())
+	router.POST("/stocktakes/:id/counts", stocktakeHandler.Count())
+	router.POST("/stocktakes/:id/commit", stocktakeHandler.Commit())
+	router.DELETE("/stocktakes/:id", stocktakeHandler.Cancel())
+	router.GET("/products/margins", handler.Margins())
+	router.GET("/suppliers", supplierHandler.GetAll())
+	router.GET("/suppliers/:id", supplierHandler.Get())
+	router.POST("/suppliers", supplierHandler.Save())
+	router.PUT("/suppliers/:id", supplierHandler.Update())
+	router.DELETE("/suppliers/:id", supplierHandler.Delete())
+	router.GET("/purchase_orders", purchaseHandler.GetAll())
+	router.GET("/purchase_orders/:id", purchaseHandler.Get())
+	router.POST("/purchase_orders", purchaseHandler.Create())
+	router.POST("/purchase_orders/:id/receive", purchaseHandler.Receive())
+	router.POST("/purchase_orders/:id/cancel", purchaseHandler.Cancel())
 
 	router.Run()
 }
 
-func TokenAuthMiddleware() gin.HandlerFunc {
-	requiredToken := os.Getenv("TOKEN")
+// reads the API tokens: TOKEN, shared and belonging to the api actor, and
+// TOKENS, a list of actor:token pairs for callers with their own token
+func Credentials() (web.Credentials, error) {
+	credentials, err := web.ParseCredentials(os.Getenv("TOKENS"))
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("TOKEN"); token != "" {
+		credentials[token] = web.TokenActor
+	}
+	return credentials, nil
+}
+
+// periodically marks reservations past their expiration as expired
+func ExpireReservations(s reservation.Service, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.ExpireDue(context.Background())
+	}
+}
+
+// periodically publishes and archives products whose scheduled time passed
+func PublishScheduled(s product.Service, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.PublishDue(context.Background())
+	}
+}
 
-	return func(c *gin.Context) {
-		token := c.GetHeader("token")
-		if token != requiredToken {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid API token"})
-			return
-		}
-		c.Next()
+// periodically purges products kept in the trash past their retention
+func PurgeTrash(s product.Service, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.PurgeDue(context.Background())
 	}
 }
@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+// Alerter is told when a stock change takes a product to its reorder point
+type Alerter interface {
+	LowStock(ctx context.Context, alert domain.StockAlert)
+}
+
+type logAlerter struct {
+	logger *log.Logger
+}
+
+// creates an alerter writing to the standard logger
+func NewLogAlerter() Alerter {
+	return &logAlerter{log.Default()}
+}
+
+func (a *logAlerter) LowStock(ctx context.Context, alert domain.StockAlert) {
+	a.logger.Printf("low stock: product %d (%s) at %d units, reorder point %d, reorder %d units",
+		alert.ProductID, alert.CodeValue, alert.Quantity, alert.ReorderPoint, alert.ReorderQuantity)
+}
+
+type webhookAlerter struct {
+	url    string
+	client *http.Client
+}
+
+// creates an alerter posting each alert as JSON to url. Posts run in the
+// background so a slow receiver never delays a stock change.
+func NewWebhookAlerter(url string) Alerter {
+	return &webhookAlerter{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (a *webhookAlerter) LowStock(ctx context.Context, alert domain.StockAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("low stock webhook: %v", err)
+		return
+	}
+	go func() {
+		resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("low stock webhook: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("low stock webhook: unexpected status %d", resp.StatusCode)
+		}
+	}()
+}
+
+type multiAlerter []Alerter
+
+// creates an alerter forwarding every alert to all alerters
+func NewMultiAlerter(alerters ...Alerter) Alerter {
+	return multiAlerter(alerters)
+}
+
+func (m multiAlerter) LowStock(ctx context.Context, alert domain.StockAlert) {
+	for _, a := range m {
+		a.LowStock(ctx, alert)
+	}
+}
@@ -0,0 +1,81 @@
+package attachment
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound           = errors.New("attachment not found")
+	ErrCreatingAttachment = errors.New("error creating attachment")
+	ErrReadingAttachment  = errors.New("error reading attachment")
+	ErrEmpty              = errors.New("attachment is empty")
+	ErrTooLarge           = errors.New("attachment is too large")
+	ErrContentType        = errors.New("attachment content type is not allowed")
+	ErrChecksum           = errors.New("attachment content does not match its checksum")
+)
+
+type Repository interface {
+	GetByProduct(productID int) []domain.Attachment
+	GetByID(productID int, id int) (domain.Attachment, error)
+	Content(a domain.Attachment) ([]byte, error)
+	Create(a domain.Attachment, content []byte) (int, error)
+	Delete(productID int, id int) error
+}
+
+type repository struct {
+	storage store.AttachmentStore
+}
+
+func NewRepository(storage store.AttachmentStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves the attachments of a product
+func (r *repository) GetByProduct(productID int) []domain.Attachment {
+	attachments, err := r.storage.GetByProduct(productID)
+	if err != nil {
+		return []domain.Attachment{}
+	}
+	return attachments
+}
+
+// search attachment of a product by ID
+func (r *repository) GetByID(productID int, id int) (domain.Attachment, error) {
+	attachment, err := r.storage.GetOne(productID, id)
+	if err != nil {
+		return domain.Attachment{}, ErrNotFound
+	}
+	return attachment, nil
+}
+
+// reads the content of an attachment
+func (r *repository) Content(a domain.Attachment) ([]byte, error) {
+	content, err := r.storage.ReadContent(a)
+	if errors.Is(err, store.ErrAttachmentNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, ErrReadingAttachment
+	}
+	return content, nil
+}
+
+// adds a new attachment
+func (r *repository) Create(a domain.Attachment, content []byte) (int, error) {
+	id, err := r.storage.AddOne(a, content)
+	if err != nil {
+		return 0, ErrCreatingAttachment
+	}
+	return id, nil
+}
+
+// deletes an attachment
+func (r *repository) Delete(productID int, id int) error {
+	if err := r.storage.DeleteOne(productID, id); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
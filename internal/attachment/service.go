@@ -0,0 +1,128 @@
+package attachment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+// DefaultMaxSize is the largest attachment accepted, in bytes
+const DefaultMaxSize = 5 << 20
+
+// content types attachments may have, as sniffed from their content
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+type Service interface {
+	GetAll(ctx context.Context, productID int) ([]domain.Attachment, error)
+	Get(ctx context.Context, productID int, id int) (domain.Attachment, error)
+	Content(ctx context.Context, productID int, id int) (domain.Attachment, []byte, error)
+	Upload(ctx context.Context, productID int, fileName string, content []byte) (domain.Attachment, error)
+	Delete(ctx context.Context, productID int, id int) error
+	MaxSize() int64
+}
+
+type service struct {
+	repo     Repository
+	products product.Service
+	maxSize  int64
+}
+
+func NewService(repo Repository, products product.Service, maxSize int64) Service {
+	return &service{repo, products, maxSize}
+}
+
+// MaxSize returns the largest attachment accepted, in bytes
+func (s *service) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *service) GetAll(ctx context.Context, productID int) ([]domain.Attachment, error) {
+	if _, err := s.products.Get(ctx, productID); err != nil {
+		return []domain.Attachment{}, err
+	}
+	return s.repo.GetByProduct(productID), nil
+}
+
+func (s *service) Get(ctx context.Context, productID int, id int) (domain.Attachment, error) {
+	return s.repo.GetByID(productID, id)
+}
+
+// Content returns an attachment along with its content, checked against the
+// checksum taken on upload
+func (s *service) Content(ctx context.Context, productID int, id int) (domain.Attachment, []byte, error) {
+	attachment, err := s.repo.GetByID(productID, id)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	content, err := s.repo.Content(attachment)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	if checksum(content) != attachment.Checksum {
+		return domain.Attachment{}, nil, ErrChecksum
+	}
+	return attachment, content, nil
+}
+
+// Upload stores a file for a product. The content type is sniffed from the
+// content rather than trusted from the caller.
+func (s *service) Upload(ctx context.Context, productID int, fileName string, content []byte) (domain.Attachment, error) {
+	if _, err := s.products.Get(ctx, productID); err != nil {
+		return domain.Attachment{}, err
+	}
+	if len(content) == 0 {
+		return domain.Attachment{}, ErrEmpty
+	}
+	if int64(len(content)) > s.maxSize {
+		return domain.Attachment{}, ErrTooLarge
+	}
+	contentType := http.DetectContentType(content)
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if !allowedContentTypes[mediaType] {
+		return domain.Attachment{}, ErrContentType
+	}
+	fileName = filepath.Base(filepath.Clean("/" + strings.ReplaceAll(fileName, "\\", "/")))
+	if fileName == "/" || fileName == "." {
+		fileName = "attachment"
+	}
+	attachment := domain.Attachment{
+		ProductID:   productID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Checksum:    checksum(content),
+		Actor:       web.Actor(ctx),
+		CreatedAt:   time.Now().UTC(),
+	}
+	id, err := s.repo.Create(attachment, content)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	attachment.ID = id
+	return attachment, nil
+}
+
+func (s *service) Delete(ctx context.Context, productID int, id int) error {
+	return s.repo.Delete(productID, id)
+}
+
+// hex encoded SHA-256 of content
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrRecordingEntry = errors.New("error recording audit entry")
+	ErrTimeRange      = errors.New("from must not be after to")
+)
+
+type Repository interface {
+	GetAll() []domain.AuditEntry
+	Create(entry domain.AuditEntry) (int, error)
+}
+
+type repository struct {
+	storage store.AuditStore
+}
+
+func NewRepository(storage store.AuditStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all audit entries
+func (r *repository) GetAll() []domain.AuditEntry {
+	entries, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.AuditEntry{}
+	}
+	return entries
+}
+
+// appends an audit entry
+func (r *repository) Create(entry domain.AuditEntry) (int, error) {
+	id, err := r.storage.AddOne(entry)
+	if err != nil {
+		return 0, ErrRecordingEntry
+	}
+	return id, nil
+}
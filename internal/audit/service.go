@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type Service interface {
+	Record(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error)
+	Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo}
+}
+
+// Record appends an entry to the audit log, stamped with the current time
+func (s *service) Record(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error) {
+	entry.CreatedAt = time.Now().UTC()
+	entry.Outcome = domain.AuditOutcomeSuccess
+	if entry.Status >= 400 {
+		entry.Outcome = domain.AuditOutcomeFailure
+	}
+	id, err := s.repo.Create(entry)
+	if err != nil {
+		return domain.AuditEntry{}, err
+	}
+	entry.ID = id
+	return entry, nil
+}
+
+// Search lists the entries of an actor, about a product and within a time
+// range, oldest first
+func (s *service) Search(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return []domain.AuditEntry{}, ErrTimeRange
+	}
+	var found = []domain.AuditEntry{}
+	for _, entry := range s.repo.GetAll() {
+		switch {
+		case filter.Actor != "" && entry.Actor != filter.Actor:
+		case filter.ProductID != 0 && entry.ProductID != filter.ProductID:
+		case !filter.From.IsZero() && entry.CreatedAt.Before(filter.From):
+		case !filter.To.IsZero() && entry.CreatedAt.After(filter.To):
+		default:
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
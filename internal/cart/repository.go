@@ -0,0 +1,58 @@
+package cart
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound         = errors.New("cart not found")
+	ErrCreatingCart     = errors.New("error creating cart")
+	ErrUpdatingCart     = errors.New("error updating cart")
+	ErrCheckedOut       = errors.New("cart already checked out")
+	ErrEmptyCart        = errors.New("cart has no lines")
+	ErrLineNotFound     = errors.New("product not in cart")
+	ErrQuantityNegative = errors.New("quantity must be greater than 0")
+)
+
+type Repository interface {
+	GetByID(id int) (domain.Cart, error)
+	Create(c domain.Cart) (int, error)
+	Update(c domain.Cart) (domain.Cart, error)
+}
+
+type repository struct {
+	storage store.CartStore
+}
+
+func NewRepository(storage store.CartStore) Repository {
+	return &repository{storage}
+}
+
+// search cart by ID
+func (r *repository) GetByID(id int) (domain.Cart, error) {
+	cart, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Cart{}, ErrNotFound
+	}
+	return cart, nil
+}
+
+// adds a new cart
+func (r *repository) Create(c domain.Cart) (int, error) {
+	id, err := r.storage.AddOne(c)
+	if err != nil {
+		return 0, ErrCreatingCart
+	}
+	return id, nil
+}
+
+// updates a cart
+func (r *repository) Update(c domain.Cart) (domain.Cart, error) {
+	if err := r.storage.UpdateOne(c); err != nil {
+		return domain.Cart{}, ErrUpdatingCart
+	}
+	return c, nil
+}
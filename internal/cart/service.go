@@ -0,0 +1,231 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/currency"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/order"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Cart, error)
+	Create(ctx context.Context, cartRequest domain.CartRequest) (domain.Cart, error)
+	AddLine(ctx context.Context, id int, line domain.LineRequest) (domain.Cart, error)
+	UpdateLine(ctx context.Context, id int, productID int, unit string, quantity int) (domain.Cart, error)
+	RemoveLine(ctx context.Context, id int, productID int, unit string) (domain.Cart, error)
+	Price(ctx context.Context, id int) (domain.Quote, error)
+	Checkout(ctx context.Context, id int) (domain.Order, error)
+}
+
+type service struct {
+	repo     Repository
+	products product.Service
+	orders   order.Service
+	locks    sync.Map
+}
+
+func NewService(repo Repository, products product.Service, orders order.Service) Service {
+	return &service{repo: repo, products: products, orders: orders}
+}
+
+// lock keeps other changes to a cart out until the returned func is called,
+// so a cart is read, changed and saved, or checked out, only once at a time
+func (s *service) lock(id int) func() {
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Cart, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) Create(ctx context.Context, cartRequest domain.CartRequest) (domain.Cart, error) {
+	now := time.Now().UTC()
+	cart := domain.Cart{
+		Status:        domain.CartStatusOpen,
+		CustomerGroup: cartRequest.CustomerGroup,
+		Currency:      currency.Normalize(cartRequest.Currency),
+		Lines:         []domain.LineRequest{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	//Reject unknown currencies up front
+	if _, err := s.products.Quote(ctx, cart.Lines, s.options(cart)); err != nil {
+		return domain.Cart{}, err
+	}
+	var err error
+	cart.ID, err = s.repo.Create(cart)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	return cart, nil
+}
+
+// AddLine adds quantity units of a product to the cart. Lines of the same
+// product are merged when they are in the same unit, an empty unit being the
+// base unit of the product.
+func (s *service) AddLine(ctx context.Context, id int, line domain.LineRequest) (domain.Cart, error) {
+	if line.Quantity <= 0 {
+		return domain.Cart{}, ErrQuantityNegative
+	}
+	unlock := s.lock(id)
+	defer unlock()
+	cart, err := s.open(id)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	line.Unit = s.unit(ctx, line.ProductID, line.Unit)
+	for i, l := range cart.Lines {
+		if s.sameLine(ctx, l, line.ProductID, line.Unit) {
+			cart.Lines[i].Quantity += line.Quantity
+			return s.save(ctx, cart)
+		}
+	}
+	cart.Lines = append(cart.Lines, line)
+	return s.save(ctx, cart)
+}
+
+// UpdateLine sets the quantity of the line of a product in a unit, its base
+// unit when empty
+func (s *service) UpdateLine(ctx context.Context, id int, productID int, unit string, quantity int) (domain.Cart, error) {
+	if quantity <= 0 {
+		return domain.Cart{}, ErrQuantityNegative
+	}
+	unlock := s.lock(id)
+	defer unlock()
+	cart, err := s.open(id)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	unit = s.unit(ctx, productID, unit)
+	for i, l := range cart.Lines {
+		if s.sameLine(ctx, l, productID, unit) {
+			cart.Lines[i].Quantity = quantity
+			return s.save(ctx, cart)
+		}
+	}
+	return domain.Cart{}, ErrLineNotFound
+}
+
+// RemoveLine removes the line of a product in a unit, its base unit when empty
+func (s *service) RemoveLine(ctx context.Context, id int, productID int, unit string) (domain.Cart, error) {
+	unlock := s.lock(id)
+	defer unlock()
+	cart, err := s.open(id)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	unit = s.unit(ctx, productID, unit)
+	for i, l := range cart.Lines {
+		if s.sameLine(ctx, l, productID, unit) {
+			cart.Lines = append(cart.Lines[:i], cart.Lines[i+1:]...)
+			return s.save(ctx, cart)
+		}
+	}
+	return domain.Cart{}, ErrLineNotFound
+}
+
+// Price quotes the cart lines with the current product prices and stock
+func (s *service) Price(ctx context.Context, id int) (domain.Quote, error) {
+	cart, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Quote{}, err
+	}
+	return s.products.Quote(ctx, cart.Lines, s.options(cart))
+}
+
+// Checkout turns the cart into an order and closes it. The cart is closed
+// before the order is placed and reopened if it can not be, so a failed
+// checkout can be retried without placing the order twice.
+func (s *service) Checkout(ctx context.Context, id int) (domain.Order, error) {
+	unlock := s.lock(id)
+	defer unlock()
+	cart, err := s.open(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if len(cart.Lines) == 0 {
+		return domain.Order{}, ErrEmptyCart
+	}
+	previous := cart
+	cart.Status = domain.CartStatusCheckedOut
+	cart.UpdatedAt = time.Now().UTC()
+	if _, err := s.repo.Update(cart); err != nil {
+		return domain.Order{}, err
+	}
+	created, err := s.orders.Create(ctx, domain.OrderRequest{
+		CustomerGroup: cart.CustomerGroup,
+		Currency:      cart.Currency,
+		Lines:         cart.Lines,
+	})
+	if err != nil {
+		return domain.Order{}, s.reopen(previous, err)
+	}
+	cart.OrderID = created.ID
+	if _, err := s.repo.Update(cart); err != nil {
+		if _, cancelErr := s.orders.Cancel(ctx, created.ID, "cart could not be checked out"); cancelErr != nil {
+			return domain.Order{}, fmt.Errorf("%w, and order %d could not be cancelled: %v", err, created.ID, cancelErr)
+		}
+		return domain.Order{}, s.reopen(previous, err)
+	}
+	return created, nil
+}
+
+// puts a cart back as it was before a failed checkout and returns err
+func (s *service) reopen(previous domain.Cart, err error) error {
+	if _, reopenErr := s.repo.Update(previous); reopenErr != nil {
+		return fmt.Errorf("%w, and the cart could not be reopened: %v", err, reopenErr)
+	}
+	return err
+}
+
+// spells out an empty unit as the base unit of the product. Units of
+// products that can not be found are kept, so their lines can still be
+// removed and adding them fails when the cart is quoted.
+func (s *service) unit(ctx context.Context, productID int, unit string) string {
+	if unit != "" {
+		return unit
+	}
+	found, err := s.products.Get(ctx, productID)
+	if err != nil {
+		return unit
+	}
+	return found.Unit()
+}
+
+// reports whether a line is the one of a product in a unit already spelled
+// out, as lines saved before units were spelled out may have none
+func (s *service) sameLine(ctx context.Context, line domain.LineRequest, productID int, unit string) bool {
+	return line.ProductID == productID && s.unit(ctx, productID, line.Unit) == unit
+}
+
+// retrieves a cart that can still be changed
+func (s *service) open(id int) (domain.Cart, error) {
+	cart, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	if cart.Status != domain.CartStatusOpen {
+		return domain.Cart{}, ErrCheckedOut
+	}
+	return cart, nil
+}
+
+// checks the cart lines are available before persisting them
+func (s *service) save(ctx context.Context, cart domain.Cart) (domain.Cart, error) {
+	if _, err := s.products.Quote(ctx, cart.Lines, s.options(cart)); err != nil {
+		return domain.Cart{}, err
+	}
+	cart.UpdatedAt = time.Now().UTC()
+	return s.repo.Update(cart)
+}
+
+func (s *service) options(cart domain.Cart) domain.PriceOptions {
+	return domain.PriceOptions{Currency: cart.Currency, CustomerGroup: cart.CustomerGroup}
+}
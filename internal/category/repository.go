@@ -0,0 +1,77 @@
+package category
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound         = errors.New("category not found")
+	ErrCreatingCategory = errors.New("error creating category")
+	ErrUpdatingCategory = errors.New("error updating category")
+	ErrParentNotFound   = errors.New("parent category not found")
+	ErrCycle            = errors.New("category can not be moved under itself or its descendants")
+	ErrHasChildren      = errors.New("category still has subcategories")
+	ErrInUse            = errors.New("category still has products")
+)
+
+type Repository interface {
+	GetAll() []domain.Category
+	GetByID(id int) (domain.Category, error)
+	Create(c domain.Category) (int, error)
+	Update(c domain.Category) (domain.Category, error)
+	Delete(id int) error
+}
+
+type repository struct {
+	storage store.CategoryStore
+}
+
+func NewRepository(storage store.CategoryStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all categories
+func (r *repository) GetAll() []domain.Category {
+	categories, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Category{}
+	}
+	return categories
+}
+
+// search category by ID
+func (r *repository) GetByID(id int) (domain.Category, error) {
+	category, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Category{}, ErrNotFound
+	}
+	return category, nil
+}
+
+// adds a new category
+func (r *repository) Create(c domain.Category) (int, error) {
+	id, err := r.storage.AddOne(c)
+	if err != nil {
+		return 0, ErrCreatingCategory
+	}
+	return id, nil
+}
+
+// updates a category
+func (r *repository) Update(c domain.Category) (domain.Category, error) {
+	if err := r.storage.UpdateOne(c); err != nil {
+		return domain.Category{}, ErrUpdatingCategory
+	}
+	return c, nil
+}
+
+// deletes a category
+func (r *repository) Delete(id int) error {
+	if err := r.storage.DeleteOne(id); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package category
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Category, error)
+	GetAll(ctx context.Context) []domain.Category
+	Save(ctx context.Context, category domain.Category) (domain.Category, error)
+	Update(ctx context.Context, category domain.Category, id int) (domain.Category, error)
+	Delete(ctx context.Context, id int) error
+	Descendants(ctx context.Context, id int) ([]int, error)
+	CodeFormat(ctx context.Context, id int) (string, error)
+}
+
+// ProductChecker reports whether any product is filed under a category
+type ProductChecker interface {
+	CategoryInUse(categoryID int) bool
+}
+
+type service struct {
+	repo     Repository
+	products ProductChecker
+}
+
+func NewService(repo Repository, products ProductChecker) Service {
+	return &service{repo, products}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Category, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Category {
+	return s.repo.GetAll()
+}
+
+func (s *service) Save(ctx context.Context, category domain.Category) (domain.Category, error) {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.CodeFormat != "" && !gtin.ValidFormat(category.CodeFormat) {
+		return domain.Category{}, gtin.ErrUnknownFormat
+	}
+	if category.ParentID != 0 {
+		if _, err := s.repo.GetByID(category.ParentID); err != nil {
+			return domain.Category{}, ErrParentNotFound
+		}
+	}
+	var err error
+	category.ID, err = s.repo.Create(category)
+	if err != nil {
+		return domain.Category{}, err
+	}
+	return category, nil
+}
+
+// Update renames or moves a category, refusing to move it under its own
+// subtree
+func (s *service) Update(ctx context.Context, category domain.Category, id int) (domain.Category, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return domain.Category{}, err
+	}
+	category.ID = id
+	category.Name = strings.TrimSpace(category.Name)
+	if category.CodeFormat != "" && !gtin.ValidFormat(category.CodeFormat) {
+		return domain.Category{}, gtin.ErrUnknownFormat
+	}
+	if category.ParentID != 0 {
+		if _, err := s.repo.GetByID(category.ParentID); err != nil {
+			return domain.Category{}, ErrParentNotFound
+		}
+		subtree, err := s.Descendants(ctx, id)
+		if err != nil {
+			return domain.Category{}, err
+		}
+		for _, descendant := range subtree {
+			if descendant == category.ParentID {
+				return domain.Category{}, ErrCycle
+			}
+		}
+	}
+	return s.repo.Update(category)
+}
+
+// Delete removes a category with no subcategories nor products
+func (s *service) Delete(ctx context.Context, id int) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return err
+	}
+	for _, c := range s.repo.GetAll() {
+		if c.ParentID == id {
+			return ErrHasChildren
+		}
+	}
+	if s.products.CategoryInUse(id) {
+		return ErrInUse
+	}
+	return s.repo.Delete(id)
+}
+
+// Descendants returns the id of a category followed by the ids of every
+// category below it
+func (s *service) Descendants(ctx context.Context, id int) ([]int, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return []int{}, err
+	}
+	children := map[int][]int{}
+	for _, c := range s.repo.GetAll() {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+	}
+	var ids = []int{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// CodeFormat returns the code format of a category, inherited from the
+// closest ancestor setting one, or empty when codes are free-form
+func (s *service) CodeFormat(ctx context.Context, id int) (string, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	for category.CodeFormat == "" && category.ParentID != 0 {
+		category, err = s.repo.GetByID(category.ParentID)
+		if err != nil {
+			return "", err
+		}
+	}
+	return category.CodeFormat, nil
+}
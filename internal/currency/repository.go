@@ -0,0 +1,66 @@
+package currency
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound          = errors.New("exchange rate not found")
+	ErrSavingRate        = errors.New("error saving exchange rate")
+	ErrInvalidCurrency   = errors.New("currency must be a 3 letter ISO 4217 code")
+	ErrRateOutOfRange    = errors.New("rate must be greater than 0")
+	ErrBaseCurrencyFixed = errors.New("base currency rate is always 1")
+	ErrInUse             = errors.New("currency still prices products or suppliers")
+)
+
+type Repository interface {
+	GetAll() []domain.ExchangeRate
+	GetByCurrency(currency string) (domain.ExchangeRate, error)
+	Save(rate domain.ExchangeRate) error
+	Delete(currency string) error
+}
+
+type repository struct {
+	storage store.RateStore
+}
+
+func NewRepository(storage store.RateStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all exchange rates
+func (r *repository) GetAll() []domain.ExchangeRate {
+	rates, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.ExchangeRate{}
+	}
+	return rates
+}
+
+// search exchange rate by currency code
+func (r *repository) GetByCurrency(currency string) (domain.ExchangeRate, error) {
+	rate, err := r.storage.GetOne(currency)
+	if err != nil {
+		return domain.ExchangeRate{}, ErrNotFound
+	}
+	return rate, nil
+}
+
+// adds or replaces an exchange rate
+func (r *repository) Save(rate domain.ExchangeRate) error {
+	if err := r.storage.SaveOne(rate); err != nil {
+		return ErrSavingRate
+	}
+	return nil
+}
+
+// deletes an exchange rate
+func (r *repository) Delete(currency string) error {
+	if err := r.storage.DeleteOne(currency); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type Service interface {
+	Base() string
+	GetAll(ctx context.Context) []domain.ExchangeRate
+	Save(ctx context.Context, rate domain.ExchangeRate) (domain.ExchangeRate, error)
+	Delete(ctx context.Context, currency string) error
+	Validate(ctx context.Context, currency string) (string, error)
+	Convert(ctx context.Context, amount float64, from string, to string) (float64, error)
+	LockRates() func()
+}
+
+// DefaultBase is the base currency when none is configured
+const DefaultBase = "USD"
+
+// UsageChecker reports whether anything is priced in a currency
+type UsageChecker interface {
+	CurrencyInUse(currency string) bool
+}
+
+type service struct {
+	repo  Repository
+	base  string
+	users []UsageChecker
+	mu    sync.RWMutex
+}
+
+// NewService creates a currency service. Rates in the repository are
+// expressed against base, which always converts at 1. Rates can not be
+// deleted while any of users is priced in their currency.
+func NewService(repo Repository, base string, users ...UsageChecker) Service {
+	return &service{repo: repo, base: Normalize(base), users: users}
+}
+
+// Normalize trims and uppercases a currency code
+func Normalize(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+func (s *service) Base() string {
+	return s.base
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.ExchangeRate {
+	rates := s.repo.GetAll()
+	return append([]domain.ExchangeRate{{Currency: s.base, Rate: 1}}, rates...)
+}
+
+func (s *service) Save(ctx context.Context, rate domain.ExchangeRate) (domain.ExchangeRate, error) {
+	rate.Currency = Normalize(rate.Currency)
+	if !IsCode(rate.Currency) {
+		return domain.ExchangeRate{}, ErrInvalidCurrency
+	}
+	if rate.Currency == s.base {
+		return domain.ExchangeRate{}, ErrBaseCurrencyFixed
+	}
+	if rate.Rate <= 0 {
+		return domain.ExchangeRate{}, ErrRateOutOfRange
+	}
+	if err := s.repo.Save(rate); err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	return rate, nil
+}
+
+// LockRates keeps rates from being deleted until the returned func is
+// called, so callers can validate a currency and save what is priced in it
+// as one step
+func (s *service) LockRates() func() {
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// Delete removes the rate of a currency nothing is priced in. Anything saved
+// under LockRates is either seen in use or validated, and turned down, after
+// the rate is gone.
+func (s *service) Delete(ctx context.Context, currency string) error {
+	currency = Normalize(currency)
+	if currency == s.base {
+		return ErrBaseCurrencyFixed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.repo.GetByCurrency(currency); err != nil {
+		return err
+	}
+	for _, user := range s.users {
+		if user.CurrencyInUse(currency) {
+			return ErrInUse
+		}
+	}
+	return s.repo.Delete(currency)
+}
+
+// Validate normalizes currency and checks there is a rate for it.
+// An empty currency resolves to the base currency.
+func (s *service) Validate(ctx context.Context, currency string) (string, error) {
+	currency = Normalize(currency)
+	if currency == "" {
+		return s.base, nil
+	}
+	if _, err := s.rate(currency); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+// Convert converts amount between two currencies going through the base
+// currency. The result is not rounded so callers can round once at the end.
+func (s *service) Convert(ctx context.Context, amount float64, from string, to string) (float64, error) {
+	fromRate, err := s.rate(Normalize(from))
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := s.rate(Normalize(to))
+	if err != nil {
+		return 0, err
+	}
+	return amount / fromRate * toRate, nil
+}
+
+func (s *service) rate(currency string) (float64, error) {
+	if currency == "" || currency == s.base {
+		return 1, nil
+	}
+	rate, err := s.repo.GetByCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+// IsCode reports whether currency is written as a 3 letter ISO 4217 code
+func IsCode(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Attachment is a file uploaded for a product, such as a picture or a spec
+// sheet. ContentType is sniffed from the content and Checksum is its
+// hex encoded SHA-256.
+type Attachment struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	Actor       string    `json:"actor"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry records one mutating API call, rejected ones included. Before
+// and After hold the product the call touched, when there is one, as it was
+// around the call.
+type AuditEntry struct {
+	ID        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	ClientIP  string          `json:"client_ip"`
+	Method    string          `json:"method"`
+	Route     string          `json:"route"`
+	Path      string          `json:"path"`
+	ProductID int             `json:"product_id,omitempty"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Before    *Product        `json:"before,omitempty"`
+	After     *Product        `json:"after,omitempty"`
+	Status    int             `json:"status"`
+	Outcome   string          `json:"outcome"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows the audit log. Zero values match every entry.
+type AuditFilter struct {
+	Actor     string
+	ProductID int
+	From      time.Time
+	To        time.Time
+}
@@ -0,0 +1,49 @@
+package domain
+
+const (
+	BundlePricingFixed      = "fixed"
+	BundlePricingComponents = "components"
+)
+
+// BundleComponent is a product and the units of it a bundle is made of
+type BundleComponent struct {
+	ProductID int `json:"product_id" binding:"required"`
+	Quantity  int `json:"quantity" binding:"required"`
+}
+
+// BundleRequest creates a bundle. With fixed pricing the bundle sells at
+// Price, with components pricing at the price of its components less
+// Discount, a fraction between 0 and 1.
+type BundleRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	CodeValue   string            `json:"code_value" binding:"required"`
+	IsPublished bool              `json:"is_published"`
+	Pricing     string            `json:"pricing" binding:"required,oneof=fixed components"`
+	Price       float64           `json:"price"`
+	Discount    float64           `json:"discount"`
+	Currency    string            `json:"currency"`
+	Components  []BundleComponent `json:"components" binding:"required,min=1,dive"`
+}
+
+// IsBundle reports whether the product is made of other products and holds
+// no stock of its own
+func (p Product) IsBundle() bool {
+	return len(p.Components) > 0
+}
+
+// DeriveFromComponents sets the quantity of a bundle to the number of whole
+// bundles its components can make and its expiration to the earliest one of
+// its components. components maps product IDs to products.
+func (p *Product) DeriveFromComponents(components map[int]Product) {
+	p.Quantity = 0
+	p.Expiration = ""
+	for i, c := range p.Components {
+		component := components[c.ProductID]
+		if available := component.Quantity / c.Quantity; i == 0 || available < p.Quantity {
+			p.Quantity = available
+		}
+		if p.Expiration == "" || expirationTime(component.Expiration).Before(expirationTime(p.Expiration)) {
+			p.Expiration = component.Expiration
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+const (
+	CartStatusOpen       = "open"
+	CartStatusCheckedOut = "checked_out"
+)
+
+type Cart struct {
+	ID            int           `json:"id"`
+	Status        string        `json:"status"`
+	CustomerGroup string        `json:"customer_group,omitempty"`
+	Currency      string        `json:"currency,omitempty"`
+	Lines         []LineRequest `json:"lines"`
+	OrderID       int           `json:"order_id,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+type CartRequest struct {
+	CustomerGroup string `json:"customer_group"`
+	Currency      string `json:"currency"`
+}
+
+type CartLineRequest struct {
+	Quantity int `json:"quantity" binding:"required"`
+}
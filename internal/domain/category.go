@@ -0,0 +1,21 @@
+package domain
+
+// Category groups products. CodeFormat, when set, is the barcode format the
+// code_value of products saved in the category and its subcategories must
+// follow.
+type Category struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name" binding:"required"`
+	ParentID   int    `json:"parent_id,omitempty"`
+	CodeFormat string `json:"code_format,omitempty"`
+}
+
+// ProductFilter narrows a product listing. CategoryID matches its descendant
+// categories too and every tag must be on the product. Name matches part of
+// the product name in the first of Locales it is translated to.
+type ProductFilter struct {
+	CategoryID int
+	Tags       []string
+	Name       string
+	Locales    []string
+}
@@ -0,0 +1,7 @@
+package domain
+
+// ExchangeRate is the amount of Currency that one unit of the base currency buys
+type ExchangeRate struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate" binding:"required"`
+}
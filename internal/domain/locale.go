@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Translation holds the name and description of a product in one locale.
+// Fields left empty fall back to the default ones on the product.
+type Translation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// NormalizeLocale lowercases a language tag, writing "es_AR" as "es-ar"
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+
+// ValidLocale reports whether a normalized locale looks like a language tag
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// Localize sets name and description from the first of the locales the
+// product is translated to, in order of preference. A regional locale such
+// as "es-ar" falls back to "es" before moving to the next one. Returns the
+// locale used, empty when the product keeps its default name.
+func (p *Product) Localize(locales []string) string {
+	for _, locale := range locales {
+		locale = NormalizeLocale(locale)
+		for locale != "" {
+			if translation, ok := p.Translations[locale]; ok {
+				if translation.Name != "" {
+					p.Name = translation.Name
+				}
+				if translation.Description != "" {
+					p.Description = translation.Description
+				}
+				return locale
+			}
+			cut := strings.LastIndex(locale, "-")
+			if cut < 0 {
+				break
+			}
+			locale = locale[:cut]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// InitialLotCode names the lot holding the units a product had before its
+// first lot was received
+const InitialLotCode = "initial"
+
+var ErrLotExpiration = errors.New("lot already received with another expiration")
+
+// Lot is a delivery of a product with its own expiration. Lots are tracked
+// per product, across warehouses.
+type Lot struct {
+	Code       string `json:"code"`
+	Quantity   int    `json:"quantity"`
+	Expiration string `json:"expiration"`
+}
+
+type LotRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required"`
+	Expiration  string `json:"expiration" binding:"required"`
+	WarehouseID int    `json:"warehouse_id"`
+	Reason      string `json:"reason"`
+}
+
+// ReceiveLot adds the units of a lot to a warehouse (the default one when 0),
+// merging them with a lot of the same code
+func (p *Product) ReceiveLot(warehouseID int, lot Lot) error {
+	if len(p.Lots) == 0 && p.Quantity > 0 {
+		p.Lots = []Lot{{Code: InitialLotCode, Quantity: p.Quantity, Expiration: p.Expiration}}
+	}
+	i := -1
+	for j, l := range p.Lots {
+		if l.Code == lot.Code {
+			if l.Expiration != lot.Expiration {
+				return ErrLotExpiration
+			}
+			i = j
+		}
+	}
+	if err := p.addWarehouseStock(warehouseID, lot.Quantity); err != nil {
+		return err
+	}
+	if i < 0 {
+		p.Lots = append(p.Lots, lot)
+	} else {
+		p.Lots[i].Quantity += lot.Quantity
+	}
+	p.sortLots()
+	p.setExpiration()
+	return nil
+}
+
+// allocates a stock change to lots: units are taken first-expired-first-out
+// and units added without a lot go to the lot expiring last
+func (p *Product) allocateLots(delta int) {
+	if len(p.Lots) == 0 {
+		return
+	}
+	p.sortLots()
+	if delta > 0 {
+		p.Lots[len(p.Lots)-1].Quantity += delta
+	}
+	for i := 0; i < len(p.Lots) && delta < 0; i++ {
+		taken := -delta
+		if taken > p.Lots[i].Quantity {
+			taken = p.Lots[i].Quantity
+		}
+		p.Lots[i].Quantity -= taken
+		delta += taken
+	}
+	p.setExpiration()
+}
+
+// sets Expiration to the earliest expiration of a lot with units left
+func (p *Product) setExpiration() {
+	for _, l := range p.Lots {
+		if l.Quantity > 0 {
+			p.Expiration = l.Expiration
+			return
+		}
+	}
+}
+
+// sorts lots by expiration, oldest first
+func (p *Product) sortLots() {
+	sort.SliceStable(p.Lots, func(i, j int) bool {
+		return expirationTime(p.Lots[i].Expiration).Before(expirationTime(p.Lots[j].Expiration))
+	})
+}
+
+func expirationTime(date string) time.Time {
+	t, _ := time.Parse("02/01/2006", date)
+	return t
+}
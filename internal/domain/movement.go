@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementWriteOff   = "write_off"
+	MovementTransfer   = "transfer"
+)
+
+// Movement is one change of a product quantity. Quantity is signed and
+// Balance is the product quantity right after the change.
+type Movement struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	WarehouseID int       `json:"warehouse_id,omitempty"`
+	Type        string    `json:"type"`
+	Quantity    int       `json:"quantity"`
+	Balance     int       `json:"balance"`
+	Lot         string    `json:"lot,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Reference   string    `json:"reference,omitempty"`
+	Actor       string    `json:"actor"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type MovementRequest struct {
+	Type        string `json:"type" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required"`
+	WarehouseID int    `json:"warehouse_id"`
+	Unit        string `json:"unit"`
+	Reason      string `json:"reason"`
+}
+
+// StockLedger is the movement trail of a product reconciled against its
+// current quantity
+type StockLedger struct {
+	ProductID     int        `json:"product_id"`
+	Quantity      int        `json:"quantity"`
+	LedgerBalance int        `json:"ledger_balance"`
+	Reconciled    bool       `json:"reconciled"`
+	Movements     []Movement `json:"movements"`
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+const (
+	OrderStatusCreated           = "created"
+	OrderStatusShipped           = "shipped"
+	OrderStatusCancelled         = "cancelled"
+	OrderStatusPartiallyReturned = "partially_returned"
+	OrderStatusReturned          = "returned"
+)
+
+type Order struct {
+	ID            int                 `json:"id"`
+	Status        string              `json:"status"`
+	CustomerGroup string              `json:"customer_group,omitempty"`
+	ReservationID int                 `json:"reservation_id,omitempty"`
+	WarehouseID   int                 `json:"warehouse_id,omitempty"`
+	Currency      string              `json:"currency"`
+	Lines         []PricedLine        `json:"lines"`
+	Subtotal      float64             `json:"subtotal"`
+	TaxRate       float64             `json:"tax_rate"`
+	TotalPrice    float64             `json:"total_price"`
+	Refunded      float64             `json:"refunded"`
+	Returns       []OrderReturn       `json:"returns"`
+	History       []OrderStatusChange `json:"history"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+type OrderStatusChange struct {
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// OrderReturn records returned units, priced at the order unit prices, and
+// the refund including the tax rate of the order
+type OrderReturn struct {
+	Lines     []PricedLine `json:"lines"`
+	Refund    float64      `json:"refund"`
+	Reason    string       `json:"reason,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type OrderRequest struct {
+	CustomerGroup string        `json:"customer_group"`
+	ReservationID int           `json:"reservation_id"`
+	WarehouseID   int           `json:"warehouse_id"`
+	Currency      string        `json:"currency"`
+	Lines         []LineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type OrderStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ReturnRequest struct {
+	Lines  []LineRequest `json:"lines" binding:"required,min=1,dive"`
+	Reason string        `json:"reason"`
+}
+
+// Returned sums the units of productID already returned
+func (o Order) Returned(productID int) int {
+	returned := 0
+	for _, r := range o.Returns {
+		for _, line := range r.Lines {
+			if line.ProductID == productID {
+				returned += line.Quantity
+			}
+		}
+	}
+	return returned
+}
+
+// SetStatus changes the order status and appends it to the history
+func (o *Order) SetStatus(status string, reason string, at time.Time) {
+	o.Status = status
+	o.History = append(o.History, OrderStatusChange{Status: status, Reason: reason, ChangedAt: at})
+}
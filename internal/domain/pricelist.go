@@ -0,0 +1,15 @@
+package domain
+
+type PriceList struct {
+	ID            int          `json:"id"`
+	CustomerGroup string       `json:"customer_group" binding:"required"`
+	Prices        []PriceBreak `json:"prices" binding:"required"`
+}
+
+// PriceBreak is the unit price of a product, in the product currency, when
+// at least MinQuantity units are bought
+type PriceBreak struct {
+	ProductID   int     `json:"product_id" binding:"required"`
+	MinQuantity int     `json:"min_quantity" binding:"required"`
+	Price       float64 `json:"price" binding:"required"`
+}
@@ -1,21 +1,70 @@
 package domain
 
+import "time"
+
 type Product struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name" binding:"required"`
-	Quantity    int     `json:"quantity" binding:"required"`
-	CodeValue   string  `json:"code_value" binding:"required"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration" binding:"required"`
-	Price       float64 `json:"price" binding:"required"`
+	ID              int                    `json:"id"`
+	Version         int                    `json:"version,omitempty"`
+	Name            string                 `json:"name" binding:"required"`
+	Description     string                 `json:"description,omitempty"`
+	Translations    map[string]Translation `json:"translations,omitempty"`
+	Quantity        int                    `json:"quantity" binding:"required"`
+	CodeValue       string                 `json:"code_value" binding:"required"`
+	IsPublished     bool                   `json:"is_published"`
+	Status          string                 `json:"status,omitempty"`
+	StatusHistory   []StatusChange         `json:"status_history,omitempty"`
+	PublishAt       *time.Time             `json:"publish_at,omitempty"`
+	UnpublishAt     *time.Time             `json:"unpublish_at,omitempty"`
+	Expiration      string                 `json:"expiration" binding:"required"`
+	Price           float64                `json:"price" binding:"required"`
+	Currency        string                 `json:"currency,omitempty"`
+	ReorderPoint    int                    `json:"reorder_point,omitempty"`
+	ReorderQuantity int                    `json:"reorder_quantity,omitempty"`
+	Stock           []WarehouseStock       `json:"stock,omitempty"`
+	Lots            []Lot                  `json:"lots,omitempty"`
+	CategoryID      int                    `json:"category_id,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+	ParentID        int                    `json:"parent_id,omitempty"`
+	Attributes      map[string]string      `json:"attributes,omitempty"`
+	Variants        *VariantSummary        `json:"variants,omitempty"`
+	Components      []BundleComponent      `json:"components,omitempty"`
+	Pricing         string                 `json:"pricing,omitempty"`
+	Discount        float64                `json:"discount,omitempty"`
+	Cost            float64                `json:"cost,omitempty"`
+	BaseUnit        string                 `json:"base_unit,omitempty"`
+	PackSizes       []PackSize             `json:"pack_sizes,omitempty"`
+	DeletedAt       *time.Time             `json:"deleted_at,omitempty"`
+	DeletedBy       string                 `json:"deleted_by,omitempty"`
+	PurgeAt         *time.Time             `json:"purge_at,omitempty"`
 }
 
 type ProductRequest struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished *bool   `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	ID              int                    `json:"id"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	Translations    map[string]Translation `json:"translations"`
+	Quantity        int                    `json:"quantity"`
+	CodeValue       string                 `json:"code_value"`
+	IsPublished     *bool                  `json:"is_published"`
+	Expiration      string                 `json:"expiration"`
+	Price           float64                `json:"price"`
+	Currency        string                 `json:"currency"`
+	ReorderPoint    *int                   `json:"reorder_point"`
+	ReorderQuantity *int                   `json:"reorder_quantity"`
+	CategoryID      *int                   `json:"category_id"`
+	Tags            []string               `json:"tags"`
+	Attributes      map[string]string      `json:"attributes"`
+	BaseUnit        string                 `json:"base_unit"`
+	PackSizes       []PackSize             `json:"pack_sizes"`
+}
+
+// StockAlert tells a product quantity fell to or below its reorder point
+type StockAlert struct {
+	ProductID       int       `json:"product_id"`
+	Name            string    `json:"name"`
+	CodeValue       string    `json:"code_value"`
+	Quantity        int       `json:"quantity"`
+	ReorderPoint    int       `json:"reorder_point"`
+	ReorderQuantity int       `json:"reorder_quantity"`
+	RaisedAt        time.Time `json:"raised_at"`
 }
@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusInReview  = "in_review"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
+// statuses a product may move to from each status
+var productStatusTransitions = map[string][]string{
+	ProductStatusDraft:     {ProductStatusInReview},
+	ProductStatusInReview:  {ProductStatusDraft, ProductStatusPublished},
+	ProductStatusPublished: {ProductStatusArchived},
+	ProductStatusArchived:  {ProductStatusDraft},
+}
+
+// StatusChange records a product moving from one publishing status to another
+type StatusChange struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+	Actor  string    `json:"actor"`
+	At     time.Time `json:"at"`
+}
+
+// StatusRequest moves a product to another publishing status
+type StatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// ScheduleRequest sets when a product is published and archived by the
+// scheduler. A missing time clears it.
+type ScheduleRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// ValidProductStatus reports whether status is a known publishing status
+func ValidProductStatus(status string) bool {
+	_, ok := productStatusTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a product may move between two statuses
+func CanTransition(from string, to string) bool {
+	for _, allowed := range productStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns the publishing status of a product. Products that never
+// changed status are published or draft as IsPublished says.
+func (p Product) State() string {
+	if p.Status != "" {
+		return p.Status
+	}
+	if p.IsPublished {
+		return ProductStatusPublished
+	}
+	return ProductStatusDraft
+}
+
+// SetState moves a product to status, recording the change and keeping
+// IsPublished in line with it
+func (p *Product) SetState(status string, reason string, actor string, at time.Time) {
+	p.StatusHistory = append(p.StatusHistory, StatusChange{
+		From:   p.State(),
+		To:     status,
+		Reason: reason,
+		Actor:  actor,
+		At:     at,
+	})
+	p.Status = status
+	p.IsPublished = status == ProductStatusPublished
+}
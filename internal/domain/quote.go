@@ -0,0 +1,33 @@
+package domain
+
+// PriceOptions tune how a list of products is priced
+type PriceOptions struct {
+	Currency      string
+	CustomerGroup string
+	ReservationID int
+	WarehouseID   int
+}
+
+// LineRequest asks for Quantity of a product in Unit, its base unit when
+// empty
+type LineRequest struct {
+	ProductID int    `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Unit      string `json:"unit,omitempty"`
+}
+
+type PricedLine struct {
+	ProductID int     `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Price     float64 `json:"price"`
+}
+
+type Quote struct {
+	Currency   string       `json:"currency"`
+	Lines      []PricedLine `json:"lines"`
+	Subtotal   float64      `json:"subtotal"`
+	TaxRate    float64      `json:"tax_rate"`
+	TotalPrice float64      `json:"total_price"`
+}
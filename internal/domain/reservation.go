@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusExpired   = "expired"
+	ReservationStatusConsumed  = "consumed"
+)
+
+type Reservation struct {
+	ID        int           `json:"id"`
+	Status    string        `json:"status"`
+	Lines     []LineRequest `json:"lines"`
+	CreatedAt time.Time     `json:"created_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+type ReservationRequest struct {
+	Lines      []LineRequest `json:"lines" binding:"required,min=1,dive"`
+	TTLSeconds int           `json:"ttl_seconds"`
+}
+
+// IsHolding reports whether the reservation still holds its units at now
+func (r Reservation) IsHolding(now time.Time) bool {
+	return r.Status == ReservationStatusActive && now.Before(r.ExpiresAt)
+}
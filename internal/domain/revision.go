@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Revision is a product as it was written at CreatedAt. Number counts the
+// revisions of the product from 1.
+type Revision struct {
+	ID        int           `json:"id"`
+	ProductID int           `json:"product_id"`
+	Number    int           `json:"number"`
+	Product   *Product      `json:"product,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// FieldChange is a product field that differs between two revisions. From
+// or To are missing when the field was not set.
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to,omitempty"`
+}
+
+// Diff lists the fields that differ between two versions of a product, by
+// their JSON name. The version number itself is left out.
+func Diff(from Product, to Product) []FieldChange {
+	from.Version, to.Version = 0, 0
+	before, after := fieldsOf(from), fieldsOf(to)
+	var names []string
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []FieldChange
+	for _, name := range names {
+		if !bytes.Equal(before[name], after[name]) {
+			changes = append(changes, FieldChange{Field: name, From: before[name], To: after[name]})
+		}
+	}
+	return changes
+}
+
+// the JSON encoded fields of a product by name
+func fieldsOf(p Product) map[string]json.RawMessage {
+	var fields = map[string]json.RawMessage{}
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(encoded, &fields)
+	return fields
+}
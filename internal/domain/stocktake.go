@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusCommitted = "committed"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+// Stocktake is a physical count session. Counts are compared against the
+// system quantity of the warehouse, or the product total without one.
+type Stocktake struct {
+	ID          int             `json:"id"`
+	Status      string          `json:"status"`
+	WarehouseID int             `json:"warehouse_id,omitempty"`
+	Counts      []StockCount    `json:"counts"`
+	Variances   []StockVariance `json:"variances,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CommittedAt *time.Time      `json:"committed_at,omitempty"`
+}
+
+// StockCount is the counted units of a product, next to the units the system
+// expected when they were counted
+type StockCount struct {
+	ProductID int `json:"product_id" binding:"required"`
+	Counted   int `json:"counted" binding:"min=0"`
+	Expected  int `json:"expected"`
+}
+
+// StockVariance is the difference between the counted and the expected units
+// of a product
+type StockVariance struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Expected  int    `json:"expected"`
+	Counted   int    `json:"counted"`
+	Variance  int    `json:"variance"`
+}
+
+type StocktakeRequest struct {
+	WarehouseID int `json:"warehouse_id"`
+}
+
+type CountRequest struct {
+	Counts []StockCount `json:"counts" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+const (
+	PurchaseOrderStatusOpen      = "open"
+	PurchaseOrderStatusReceived  = "received"
+	PurchaseOrderStatusCancelled = "cancelled"
+)
+
+// Supplier sells us products under its own codes, at a cost in its currency
+type Supplier struct {
+	ID       int               `json:"id"`
+	Name     string            `json:"name" binding:"required"`
+	Email    string            `json:"email"`
+	Currency string            `json:"currency"`
+	Products []SupplierProduct `json:"products" binding:"dive"`
+}
+
+type SupplierProduct struct {
+	ProductID    int     `json:"product_id" binding:"required"`
+	SupplierCode string  `json:"supplier_code"`
+	Unit         string  `json:"unit"`
+	Cost         float64 `json:"cost" binding:"required"`
+}
+
+type PurchaseOrder struct {
+	ID          int                 `json:"id"`
+	Status      string              `json:"status"`
+	SupplierID  int                 `json:"supplier_id"`
+	WarehouseID int                 `json:"warehouse_id,omitempty"`
+	Currency    string              `json:"currency"`
+	Lines       []PurchaseOrderLine `json:"lines"`
+	Total       float64             `json:"total"`
+	CreatedAt   time.Time           `json:"created_at"`
+	ReceivedAt  *time.Time          `json:"received_at,omitempty"`
+}
+
+type PurchaseOrderLine struct {
+	ProductID    int     `json:"product_id"`
+	SupplierCode string  `json:"supplier_code,omitempty"`
+	Quantity     int     `json:"quantity"`
+	Unit         string  `json:"unit,omitempty"`
+	UnitCost     float64 `json:"unit_cost"`
+}
+
+// PurchaseOrderRequest lines are costed at the supplier cost of each product
+// and counted in the unit the supplier sells it in
+type PurchaseOrderRequest struct {
+	SupplierID  int           `json:"supplier_id" binding:"required"`
+	WarehouseID int           `json:"warehouse_id"`
+	Lines       []LineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// Margin compares the price of a product with its average cost, both in the
+// product currency
+type Margin struct {
+	ProductID     int     `json:"product_id"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	Cost          float64 `json:"cost"`
+	Margin        float64 `json:"margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
@@ -0,0 +1,6 @@
+package domain
+
+// InTrash reports whether a product was deleted and is waiting to be purged
+func (p Product) InTrash() bool {
+	return p.DeletedAt != nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "errors"
+
+// DefaultBaseUnit is the unit Quantity counts when a product sets none
+const DefaultBaseUnit = "unit"
+
+var ErrUnknownUnit = errors.New("unknown unit of measure for product")
+
+// PackSize is a unit holding Factor base units, like a case of 12. When
+// Price is set a whole pack sells at it instead of Factor times the base
+// price.
+type PackSize struct {
+	Unit   string  `json:"unit" binding:"required"`
+	Factor int     `json:"factor" binding:"required"`
+	Price  float64 `json:"price,omitempty"`
+}
+
+// Unit returns the unit Quantity counts
+func (p Product) Unit() string {
+	if p.BaseUnit == "" {
+		return DefaultBaseUnit
+	}
+	return p.BaseUnit
+}
+
+// Pack returns the pack size of a unit, where the base unit, also written as
+// an empty unit, is a pack of one
+func (p Product) Pack(unit string) (PackSize, error) {
+	if unit == "" || unit == p.Unit() {
+		return PackSize{Unit: p.Unit(), Factor: 1}, nil
+	}
+	for _, pack := range p.PackSizes {
+		if pack.Unit == unit {
+			return pack, nil
+		}
+	}
+	return PackSize{}, ErrUnknownUnit
+}
+
+// ToBase converts a quantity in unit to base units
+func (p Product) ToBase(unit string, quantity int) (int, error) {
+	pack, err := p.Pack(unit)
+	if err != nil {
+		return 0, err
+	}
+	return quantity * pack.Factor, nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+// VariantSummary aggregates the variants of a parent product
+type VariantSummary struct {
+	Count    int     `json:"count"`
+	Quantity int     `json:"quantity"`
+	MinPrice float64 `json:"min_price"`
+	MaxPrice float64 `json:"max_price"`
+}
+
+// VariantRequest creates a variant of a product. Name, description,
+// translations, category, tags and currency are taken from the parent.
+type VariantRequest struct {
+	CodeValue   string            `json:"code_value" binding:"required"`
+	Quantity    int               `json:"quantity" binding:"required"`
+	Expiration  string            `json:"expiration" binding:"required"`
+	Price       float64           `json:"price" binding:"required"`
+	IsPublished bool              `json:"is_published"`
+	Attributes  map[string]string `json:"attributes"`
+}
+
+// Inherit copies the fields a variant shares with its parent
+func (p *Product) Inherit(parent Product) {
+	p.ParentID = parent.ID
+	p.Name = parent.Name
+	p.Description = parent.Description
+	p.Translations = parent.Translations
+	p.CategoryID = parent.CategoryID
+	p.Tags = parent.Tags
+	p.Currency = parent.Currency
+}
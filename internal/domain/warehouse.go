@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+)
+
+// DefaultWarehouseID holds the stock of products never split by warehouse
+const DefaultWarehouseID = 1
+
+var ErrWarehouseStock = errors.New("insufficient stock in warehouse")
+
+type Warehouse struct {
+	ID   int    `json:"id"`
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+type WarehouseStock struct {
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`
+}
+
+type TransferRequest struct {
+	FromWarehouseID int    `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   int    `json:"to_warehouse_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required"`
+	Reason          string `json:"reason"`
+}
+
+// WarehouseQuantity returns the units of the product in a warehouse
+func (p Product) WarehouseQuantity(warehouseID int) int {
+	if len(p.Stock) == 0 {
+		if warehouseID == DefaultWarehouseID {
+			return p.Quantity
+		}
+		return 0
+	}
+	for _, s := range p.Stock {
+		if s.WarehouseID == warehouseID {
+			return s.Quantity
+		}
+	}
+	return 0
+}
+
+// AddStock adds delta units to a warehouse and keeps Quantity as the total
+// of every warehouse. With warehouseID 0 units are added to the default
+// warehouse and taken from the default one first, then by warehouse ID.
+// Lots follow the change as described in allocateLots.
+func (p *Product) AddStock(warehouseID int, delta int) error {
+	if err := p.addWarehouseStock(warehouseID, delta); err != nil {
+		return err
+	}
+	p.allocateLots(delta)
+	return nil
+}
+
+// MoveStock moves units between warehouses. Lots are not kept by warehouse,
+// so they are left as they are.
+func (p *Product) MoveStock(fromWarehouseID int, toWarehouseID int, quantity int) error {
+	if err := p.addWarehouseStock(fromWarehouseID, -quantity); err != nil {
+		return err
+	}
+	return p.addWarehouseStock(toWarehouseID, quantity)
+}
+
+func (p *Product) addWarehouseStock(warehouseID int, delta int) error {
+	if len(p.Stock) == 0 && p.Quantity != 0 {
+		p.Stock = []WarehouseStock{{WarehouseID: DefaultWarehouseID, Quantity: p.Quantity}}
+	}
+	if warehouseID == 0 && delta < 0 {
+		return p.takeAnywhere(-delta)
+	}
+	if warehouseID == 0 {
+		warehouseID = DefaultWarehouseID
+	}
+	i := p.stockIndex(warehouseID)
+	if p.Stock[i].Quantity+delta < 0 {
+		return ErrWarehouseStock
+	}
+	p.Stock[i].Quantity += delta
+	p.sumStock()
+	return nil
+}
+
+// takes units from the default warehouse first and then by warehouse ID
+func (p *Product) takeAnywhere(units int) error {
+	if units > p.Quantity {
+		return ErrWarehouseStock
+	}
+	sort.Slice(p.Stock, func(i, j int) bool { return p.Stock[i].WarehouseID < p.Stock[j].WarehouseID })
+	take := func(i int) {
+		taken := units
+		if taken > p.Stock[i].Quantity {
+			taken = p.Stock[i].Quantity
+		}
+		p.Stock[i].Quantity -= taken
+		units -= taken
+	}
+	for i := range p.Stock {
+		if p.Stock[i].WarehouseID == DefaultWarehouseID {
+			take(i)
+		}
+	}
+	for i := range p.Stock {
+		take(i)
+	}
+	p.sumStock()
+	return nil
+}
+
+// returns the position of a warehouse in Stock, adding it when missing
+func (p *Product) stockIndex(warehouseID int) int {
+	for i, s := range p.Stock {
+		if s.WarehouseID == warehouseID {
+			return i
+		}
+	}
+	p.Stock = append(p.Stock, WarehouseStock{WarehouseID: warehouseID})
+	sort.Slice(p.Stock, func(i, j int) bool { return p.Stock[i].WarehouseID < p.Stock[j].WarehouseID })
+	return p.stockIndex(warehouseID)
+}
+
+func (p *Product) sumStock() {
+	p.Quantity = 0
+	for _, s := range p.Stock {
+		p.Quantity += s.Quantity
+	}
+}
@@ -0,0 +1,51 @@
+package ledger
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrRecordingMovement = errors.New("error recording stock movement")
+	ErrInvalidType       = errors.New("type must be one of receipt, adjustment or write_off")
+	ErrQuantitySign      = errors.New("quantity sign does not match movement type")
+)
+
+type Repository interface {
+	GetByProduct(productID int) []domain.Movement
+	Create(movements []domain.Movement) ([]domain.Movement, error)
+}
+
+type repository struct {
+	storage store.MovementStore
+}
+
+func NewRepository(storage store.MovementStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves the movements of a product, oldest first
+func (r *repository) GetByProduct(productID int) []domain.Movement {
+	var movements = []domain.Movement{}
+	list, err := r.storage.GetAll()
+	if err != nil {
+		return movements
+	}
+	for _, movement := range list {
+		if movement.ProductID == productID {
+			movements = append(movements, movement)
+		}
+	}
+	return movements
+}
+
+// appends movements to the ledger
+func (r *repository) Create(movements []domain.Movement) ([]domain.Movement, error) {
+	created, err := r.storage.AddMany(movements)
+	if err != nil {
+		return nil, ErrRecordingMovement
+	}
+	return created, nil
+}
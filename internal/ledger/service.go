@@ -0,0 +1,98 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
+)
+
+type Service interface {
+	GetByProduct(ctx context.Context, productID int) []domain.Movement
+	Record(ctx context.Context, movements []domain.Movement) ([]domain.Movement, error)
+	Reconcile(ctx context.Context, product domain.Product) domain.StockLedger
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo}
+}
+
+func (s *service) GetByProduct(ctx context.Context, productID int) []domain.Movement {
+	return s.repo.GetByProduct(productID)
+}
+
+// Record appends movements stamped with the actor of ctx. Products moved for
+// the first time get an opening adjustment so the ledger adds up to the
+// quantity they had before.
+func (s *service) Record(ctx context.Context, movements []domain.Movement) ([]domain.Movement, error) {
+	now := time.Now().UTC()
+	actor := web.Actor(ctx)
+	var records = []domain.Movement{}
+	var moved = map[int]bool{}
+	for _, movement := range movements {
+		opening := movement.Balance - movement.Quantity
+		if movement.Type == domain.MovementTransfer {
+			//Transfers do not change the total quantity
+			opening = movement.Balance
+		}
+		first := !moved[movement.ProductID] && len(s.repo.GetByProduct(movement.ProductID)) == 0
+		moved[movement.ProductID] = true
+		if opening != 0 && first {
+			records = append(records, domain.Movement{
+				ProductID: movement.ProductID,
+				Type:      domain.MovementAdjustment,
+				Quantity:  opening,
+				Balance:   opening,
+				Reason:    "opening balance",
+				Actor:     actor,
+				CreatedAt: now,
+			})
+		}
+		movement.Actor = actor
+		movement.CreatedAt = now
+		records = append(records, movement)
+	}
+	return s.repo.Create(records)
+}
+
+// Reconcile sums the movements of product and checks they match its quantity.
+// A product never moved is reconciled by definition.
+func (s *service) Reconcile(ctx context.Context, product domain.Product) domain.StockLedger {
+	movements := s.repo.GetByProduct(product.ID)
+	balance := 0
+	for _, movement := range movements {
+		balance += movement.Quantity
+	}
+	return domain.StockLedger{
+		ProductID:     product.ID,
+		Quantity:      product.Quantity,
+		LedgerBalance: balance,
+		Reconciled:    len(movements) == 0 || balance == product.Quantity,
+		Movements:     movements,
+	}
+}
+
+// ValidateRequest checks a manually entered movement: receipts add stock,
+// write-offs remove it and adjustments go either way. Sales and returns are
+// only recorded by orders.
+func ValidateRequest(movementRequest domain.MovementRequest) error {
+	switch movementRequest.Type {
+	case domain.MovementReceipt:
+		if movementRequest.Quantity <= 0 {
+			return ErrQuantitySign
+		}
+	case domain.MovementWriteOff:
+		if movementRequest.Quantity >= 0 {
+			return ErrQuantitySign
+		}
+	case domain.MovementAdjustment:
+	default:
+		return ErrInvalidType
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package order
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound       = errors.New("order not found")
+	ErrCreatingOrder  = errors.New("error creating order")
+	ErrUpdatingOrder  = errors.New("error updating order")
+	ErrNotCancellable = errors.New("only orders not shipped yet can be cancelled")
+	ErrNotShippable   = errors.New("only created orders can be shipped")
+	ErrNotReturnable  = errors.New("only shipped orders can be returned")
+	ErrReturnExceeded = errors.New("returned quantity exceeds quantity left on the order")
+)
+
+type Repository interface {
+	GetAll() []domain.Order
+	GetByID(id int) (domain.Order, error)
+	Create(o domain.Order) (int, error)
+	Update(o domain.Order) (domain.Order, error)
+}
+
+type repository struct {
+	storage store.OrderStore
+}
+
+func NewRepository(storage store.OrderStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all orders
+func (r *repository) GetAll() []domain.Order {
+	orders, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Order{}
+	}
+	return orders
+}
+
+// search order by ID
+func (r *repository) GetByID(id int) (domain.Order, error) {
+	order, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Order{}, ErrNotFound
+	}
+	return order, nil
+}
+
+// adds a new order
+func (r *repository) Create(o domain.Order) (int, error) {
+	id, err := r.storage.AddOne(o)
+	if err != nil {
+		return 0, ErrCreatingOrder
+	}
+	return id, nil
+}
+
+// updates an order
+func (r *repository) Update(o domain.Order) (domain.Order, error) {
+	if err := r.storage.UpdateOne(o); err != nil {
+		return domain.Order{}, ErrUpdatingOrder
+	}
+	return o, nil
+}
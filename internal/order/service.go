@@ -0,0 +1,235 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/reservation"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Order, error)
+	GetAll(ctx context.Context) []domain.Order
+	Create(ctx context.Context, orderRequest domain.OrderRequest) (domain.Order, error)
+	Ship(ctx context.Context, id int, reason string) (domain.Order, error)
+	Cancel(ctx context.Context, id int, reason string) (domain.Order, error)
+	Return(ctx context.Context, id int, returnRequest domain.ReturnRequest) (domain.Order, error)
+}
+
+type service struct {
+	repo         Repository
+	products     product.Service
+	reservations reservation.Service
+}
+
+func NewService(repo Repository, products product.Service, reservations reservation.Service) Service {
+	return &service{repo, products, reservations}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Order, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Order {
+	return s.repo.GetAll()
+}
+
+// Create prices the order lines like consumer_price does, then takes the
+// units out of stock for every line or for none. Units held by the given
+// reservation are available to the order, which consumes the reservation.
+// The order is persisted first so the sale movements can reference it; if the
+// stock can not be taken afterwards the order is left cancelled.
+func (s *service) Create(ctx context.Context, orderRequest domain.OrderRequest) (domain.Order, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	if orderRequest.ReservationID != 0 {
+		held, err := s.reservations.Get(ctx, orderRequest.ReservationID)
+		if err != nil {
+			return domain.Order{}, err
+		}
+		if !held.IsHolding(time.Now()) {
+			return domain.Order{}, reservation.ErrNotActive
+		}
+	}
+	quote, err := s.products.Quote(ctx, orderRequest.Lines, domain.PriceOptions{
+		Currency:      orderRequest.Currency,
+		CustomerGroup: orderRequest.CustomerGroup,
+		ReservationID: orderRequest.ReservationID,
+		WarehouseID:   orderRequest.WarehouseID,
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	now := time.Now().UTC()
+	order := domain.Order{
+		CustomerGroup: orderRequest.CustomerGroup,
+		ReservationID: orderRequest.ReservationID,
+		WarehouseID:   orderRequest.WarehouseID,
+		Currency:      quote.Currency,
+		Lines:         quote.Lines,
+		Subtotal:      quote.Subtotal,
+		TaxRate:       quote.TaxRate,
+		TotalPrice:    quote.TotalPrice,
+		Returns:       []domain.OrderReturn{},
+		CreatedAt:     now,
+	}
+	order.SetStatus(domain.OrderStatusCreated, "", now)
+	order.ID, err = s.repo.Create(order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	deltas := map[int]int{}
+	for _, line := range quote.Lines {
+		deltas[line.ProductID] = -line.Quantity
+	}
+	err = s.products.AdjustStock(ctx, deltas, domain.Movement{
+		Type:        domain.MovementSale,
+		Reference:   reference(order.ID),
+		WarehouseID: order.WarehouseID,
+	})
+	if err != nil {
+		order.SetStatus(domain.OrderStatusCancelled, "stock could not be taken", time.Now().UTC())
+		_, _ = s.repo.Update(order)
+		return domain.Order{}, err
+	}
+	if orderRequest.ReservationID != 0 {
+		_, _ = s.reservations.Consume(ctx, orderRequest.ReservationID)
+	}
+	return order, nil
+}
+
+func (s *service) Ship(ctx context.Context, id int, reason string) (domain.Order, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if order.Status != domain.OrderStatusCreated {
+		return domain.Order{}, ErrNotShippable
+	}
+	order.SetStatus(domain.OrderStatusShipped, reason, time.Now().UTC())
+	return s.repo.Update(order)
+}
+
+// Cancel puts every unit of an order not shipped yet back in stock and
+// refunds its total
+func (s *service) Cancel(ctx context.Context, id int, reason string) (domain.Order, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if order.Status != domain.OrderStatusCreated {
+		return domain.Order{}, ErrNotCancellable
+	}
+	deltas := map[int]int{}
+	for _, line := range order.Lines {
+		deltas[line.ProductID] = line.Quantity
+	}
+	previous := order
+	order.Refunded = order.TotalPrice
+	order.SetStatus(domain.OrderStatusCancelled, reason, time.Now().UTC())
+	return s.restock(ctx, previous, order, deltas, "order cancelled")
+}
+
+// Return puts returned units of a shipped order back in stock. The refund is
+// priced at the order unit prices with the tax rate the order was charged,
+// not the tier the returned units alone would fall in.
+func (s *service) Return(ctx context.Context, id int, returnRequest domain.ReturnRequest) (domain.Order, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	previous := order
+	previous.Returns = append([]domain.OrderReturn{}, order.Returns...)
+	previous.History = append([]domain.OrderStatusChange{}, order.History...)
+	if order.Status != domain.OrderStatusShipped && order.Status != domain.OrderStatusPartiallyReturned {
+		return domain.Order{}, ErrNotReturnable
+	}
+	now := time.Now().UTC()
+	orderReturn := domain.OrderReturn{Lines: []domain.PricedLine{}, Reason: returnRequest.Reason, CreatedAt: now}
+	//Order lines hold base units, so packs are returned as the units they hold
+	deltas := map[int]int{}
+	var subtotal float64
+	for _, requested := range returnRequest.Lines {
+		quantity := requested.Quantity
+		if requested.Unit != "" {
+			returned, err := s.products.Get(ctx, requested.ProductID)
+			if err != nil {
+				return domain.Order{}, err
+			}
+			pack, err := returned.Pack(requested.Unit)
+			if err != nil {
+				return domain.Order{}, err
+			}
+			quantity *= pack.Factor
+		}
+		deltas[requested.ProductID] += quantity
+	}
+	for _, line := range order.Lines {
+		quantity, ok := deltas[line.ProductID]
+		if !ok {
+			continue
+		}
+		if quantity <= 0 || quantity > line.Quantity-order.Returned(line.ProductID) {
+			return domain.Order{}, ErrReturnExceeded
+		}
+		subtotal += line.UnitPrice * float64(quantity)
+		line.Quantity = quantity
+		line.Price = roundFloat(line.UnitPrice*float64(quantity), 2)
+		orderReturn.Lines = append(orderReturn.Lines, line)
+	}
+	if len(orderReturn.Lines) != len(deltas) {
+		return domain.Order{}, ErrReturnExceeded
+	}
+	orderReturn.Refund = roundFloat(subtotal*(1+order.TaxRate), 2)
+	order.Returns = append(order.Returns, orderReturn)
+	order.Refunded = roundFloat(order.Refunded+orderReturn.Refund, 2)
+
+	status := domain.OrderStatusReturned
+	for _, line := range order.Lines {
+		if order.Returned(line.ProductID) < line.Quantity {
+			status = domain.OrderStatusPartiallyReturned
+		}
+	}
+	order.SetStatus(status, returnRequest.Reason, now)
+	return s.restock(ctx, previous, order, deltas, returnRequest.Reason)
+}
+
+// saves the order and puts units back in stock, restoring the previous order
+// if the stock can not be put back
+func (s *service) restock(ctx context.Context, previous domain.Order, order domain.Order, deltas map[int]int, reason string) (domain.Order, error) {
+	updated, err := s.repo.Update(order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	err = s.products.AdjustStock(ctx, deltas, domain.Movement{
+		Type:        domain.MovementReturn,
+		Reason:      reason,
+		Reference:   reference(order.ID),
+		WarehouseID: order.WarehouseID,
+	})
+	if err != nil {
+		_, _ = s.repo.Update(previous)
+		return domain.Order{}, err
+	}
+	return updated, nil
+}
+
+// identifies an order in stock movements
+func reference(id int) string {
+	return fmt.Sprintf("order %d", id)
+}
+
+func roundFloat(val float64, precision uint) float64 {
+	ratio := math.Pow(10, float64(precision))
+	return math.Round(val*ratio) / ratio
+}
@@ -0,0 +1,94 @@
+package pricelist
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound              = errors.New("price list not found")
+	ErrCreatingPriceList     = errors.New("error creating price list")
+	ErrUpdatingPriceList     = errors.New("error updating price list")
+	ErrAlreadyExists         = errors.New("customer_group already has a price list")
+	ErrPriceOutOfRange       = errors.New("price must be greater than 0")
+	ErrMinQuantityOutOfRange = errors.New("min_quantity must be greater than 0")
+	ErrDuplicatedBreak       = errors.New("duplicated min_quantity for product")
+)
+
+type Repository interface {
+	GetAll() []domain.PriceList
+	GetByID(id int) (domain.PriceList, error)
+	GetByCustomerGroup(customerGroup string) (domain.PriceList, error)
+	Create(p domain.PriceList) (int, error)
+	Update(p domain.PriceList) (domain.PriceList, error)
+	Delete(id int) error
+}
+
+type repository struct {
+	storage store.PriceListStore
+}
+
+func NewRepository(storage store.PriceListStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all price lists
+func (r *repository) GetAll() []domain.PriceList {
+	priceLists, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.PriceList{}
+	}
+	return priceLists
+}
+
+// search price list by ID
+func (r *repository) GetByID(id int) (domain.PriceList, error) {
+	priceList, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.PriceList{}, ErrNotFound
+	}
+	return priceList, nil
+}
+
+// search price list by customer group
+func (r *repository) GetByCustomerGroup(customerGroup string) (domain.PriceList, error) {
+	for _, priceList := range r.GetAll() {
+		if priceList.CustomerGroup == customerGroup {
+			return priceList, nil
+		}
+	}
+	return domain.PriceList{}, ErrNotFound
+}
+
+// adds a new price list
+func (r *repository) Create(p domain.PriceList) (int, error) {
+	if _, err := r.GetByCustomerGroup(p.CustomerGroup); err == nil {
+		return 0, ErrAlreadyExists
+	}
+	id, err := r.storage.AddOne(p)
+	if err != nil {
+		return 0, ErrCreatingPriceList
+	}
+	return id, nil
+}
+
+// updates a price list
+func (r *repository) Update(p domain.PriceList) (domain.PriceList, error) {
+	if existing, err := r.GetByCustomerGroup(p.CustomerGroup); err == nil && existing.ID != p.ID {
+		return domain.PriceList{}, ErrAlreadyExists
+	}
+	if err := r.storage.UpdateOne(p); err != nil {
+		return domain.PriceList{}, ErrUpdatingPriceList
+	}
+	return p, nil
+}
+
+// deletes a price list
+func (r *repository) Delete(id int) error {
+	if err := r.storage.DeleteOne(id); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
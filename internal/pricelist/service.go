@@ -0,0 +1,111 @@
+package pricelist
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.PriceList, error)
+	GetAll(ctx context.Context) []domain.PriceList
+	Save(ctx context.Context, priceList domain.PriceList) (domain.PriceList, error)
+	Update(ctx context.Context, priceList domain.PriceList, id int) (domain.PriceList, error)
+	Delete(ctx context.Context, id int) error
+	UnitPrice(ctx context.Context, customerGroup string, productID int, quantity int) (float64, bool)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.PriceList, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.PriceList {
+	return s.repo.GetAll()
+}
+
+func (s *service) Save(ctx context.Context, priceList domain.PriceList) (domain.PriceList, error) {
+	priceList, err := validate(priceList)
+	if err != nil {
+		return domain.PriceList{}, err
+	}
+	priceList.ID, err = s.repo.Create(priceList)
+	if err != nil {
+		return domain.PriceList{}, err
+	}
+	return priceList, nil
+}
+
+func (s *service) Update(ctx context.Context, priceList domain.PriceList, id int) (domain.PriceList, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return domain.PriceList{}, err
+	}
+	priceList, err := validate(priceList)
+	if err != nil {
+		return domain.PriceList{}, err
+	}
+	priceList.ID = id
+	return s.repo.Update(priceList)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(id)
+}
+
+// UnitPrice returns the price of the highest quantity break of the customer
+// group price list that quantity reaches. The boolean is false when the group
+// has no price list or no break applies, so callers fall back to the base price.
+func (s *service) UnitPrice(ctx context.Context, customerGroup string, productID int, quantity int) (float64, bool) {
+	priceList, err := s.repo.GetByCustomerGroup(normalizeGroup(customerGroup))
+	if err != nil {
+		return 0, false
+	}
+	var price float64
+	var found bool
+	var reached int
+	for _, b := range priceList.Prices {
+		if b.ProductID == productID && b.MinQuantity <= quantity && b.MinQuantity > reached {
+			price, found, reached = b.Price, true, b.MinQuantity
+		}
+	}
+	return price, found
+}
+
+// validates price breaks and sorts them by product and quantity
+func validate(priceList domain.PriceList) (domain.PriceList, error) {
+	priceList.CustomerGroup = normalizeGroup(priceList.CustomerGroup)
+	seen := map[[2]int]bool{}
+	for _, b := range priceList.Prices {
+		if b.MinQuantity <= 0 {
+			return domain.PriceList{}, ErrMinQuantityOutOfRange
+		}
+		if b.Price <= 0 {
+			return domain.PriceList{}, ErrPriceOutOfRange
+		}
+		key := [2]int{b.ProductID, b.MinQuantity}
+		if seen[key] {
+			return domain.PriceList{}, ErrDuplicatedBreak
+		}
+		seen[key] = true
+	}
+	sort.Slice(priceList.Prices, func(i, j int) bool {
+		if priceList.Prices[i].ProductID != priceList.Prices[j].ProductID {
+			return priceList.Prices[i].ProductID < priceList.Prices[j].ProductID
+		}
+		return priceList.Prices[i].MinQuantity < priceList.Prices[j].MinQuantity
+	})
+	return priceList, nil
+}
+
+func normalizeGroup(customerGroup string) string {
+	return strings.ToLower(strings.TrimSpace(customerGroup))
+}
@@ -2,8 +2,11 @@ package product
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
 	"github.com/hernan-hdiaz/go-web/pkg/store"
 )
 
@@ -15,16 +18,54 @@ var (
 	ErrDateOutOfRange     = errors.New("expiration must be after 01/01/2023")
 	ErrPriceOutOfRange    = errors.New("price must be greater than 0")
 	ErrQuantityOutOfRange = errors.New("quantity must be greater than 0")
+	ErrAdjustingStock     = errors.New("error adjusting stock")
+	ErrReorderOutOfRange  = errors.New("reorder_point and reorder_quantity can not be negative")
+	ErrSameWarehouse      = errors.New("transfer needs two different warehouses")
+	ErrExpirationFromLots = errors.New("expiration is computed from lots and can not be set")
+	ErrNestedVariant      = errors.New("a variant can not have variants")
+	ErrSharedWithParent   = errors.New("name, description, translations, category, tags and currency are set on the parent product")
+	ErrHasVariants        = errors.New("product still has variants")
+	ErrNestedBundle       = errors.New("a bundle can not be a component of another bundle")
+	ErrBundleStock        = errors.New("bundle stock comes from its components")
+	ErrInBundle           = errors.New("product is a component of a bundle")
+	ErrDiscountOutOfRange = errors.New("discount must be between 0 and 1")
+	ErrPackSize           = errors.New("pack sizes need distinct units, a factor over 1 and a price not below 0")
+	ErrTranslation        = errors.New("translations need a valid locale and a name or description")
+	ErrInvalidStatus      = errors.New("status must be draft, in_review, published or archived")
+	ErrTransition         = errors.New("product can not move to that status")
+	ErrSchedule           = errors.New("publish_at needs the product in review and unpublish_at must come after it")
+	ErrNotInTrash         = errors.New("product not found in the trash")
+	ErrRestoreDependency  = errors.New("restore the parent and component products first")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrVersionConflict    = errors.New("product was changed by someone else, read it again")
 )
 
 type Repository interface {
 	GetAll() []domain.Product
 	GetByID(id int) (domain.Product, error)
 	SearchPriceGt(price float64) []domain.Product
+	SearchLowStock() []domain.Product
 	Create(p domain.Product) (int, error)
 	Update(id int, p domain.Product) (domain.Product, error)
 	Delete(id int) error
+	GetTrash() []domain.Product
+	GetTrashed(id int) (domain.Product, error)
+	Trash(id int, ifVersion *int, actor string, deletedAt time.Time, purgeAt time.Time) error
+	Restore(id int) (domain.Product, error)
+	GetRevisions(id int) []domain.Revision
+	LastModified() time.Time
 	ValidateCodeValue(codeValue string) bool
+	AdjustQuantities(warehouseID int, deltas map[int]int) (map[int]int, error)
+	RestoreQuantities(previous []domain.Product) error
+	TransferQuantity(id int, fromWarehouseID int, toWarehouseID int, quantity int) (domain.Product, error)
+	WarehouseInUse(warehouseID int) bool
+	CategoryInUse(categoryID int) bool
+	CurrencyInUse(currency string) bool
+	GetVariants(parentID int) []domain.Product
+	InBundle(id int) bool
+	GetByCode(codeValue string) (domain.Product, error)
+	SearchByCategoryAndTags(categoryIDs []int, tags []string) []domain.Product
+	ReceiveLot(id int, warehouseID int, lot domain.Lot) (domain.Product, error)
 }
 
 type repository struct {
@@ -69,6 +110,21 @@ func (r *repository) SearchPriceGt(price float64) []domain.Product {
 	return products
 }
 
+// search for products at or below their reorder point
+func (r *repository) SearchLowStock() []domain.Product {
+	var products = []domain.Product{}
+	list, err := r.storage.GetAll()
+	if err != nil {
+		return products
+	}
+	for _, product := range list {
+		if product.ReorderPoint > 0 && product.Quantity <= product.ReorderPoint {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
 // adds a new product
 func (r *repository) Create(p domain.Product) (int, error) {
 	if validation := r.ValidateCodeValue(p.CodeValue); !validation {
@@ -82,21 +138,105 @@ func (r *repository) Create(p domain.Product) (int, error) {
 	return p.ID, nil
 }
 
-// validates if the code value already exist on the product list
+// validates if the code value already exist on the product list. Codes of
+// products in the trash stay taken until they are purged.
 func (r *repository) ValidateCodeValue(codeValue string) bool {
 	list, err := r.storage.GetAll()
 	if err != nil {
 		return false
 	}
+	trashed, err := r.storage.GetTrash()
+	if err != nil {
+		return false
+	}
+	list = append(list, trashed...)
+	canonical, isGTIN := gtin.Canonical(codeValue)
 	for _, product := range list {
 		if product.CodeValue == codeValue {
 			return false
 		}
+		//The same GTIN written with another number of leading zeros
+		if other, ok := gtin.Canonical(product.CodeValue); isGTIN && ok && other == canonical {
+			return false
+		}
 	}
 	return true
 }
 
-// deletes a product
+// search product by code value, matching GTINs in any of their lengths
+func (r *repository) GetByCode(codeValue string) (domain.Product, error) {
+	codeValue = gtin.Normalize(codeValue)
+	canonical, isGTIN := gtin.Canonical(codeValue)
+	for _, product := range r.GetAll() {
+		if gtin.Normalize(product.CodeValue) == codeValue {
+			return product, nil
+		}
+		if other, ok := gtin.Canonical(product.CodeValue); isGTIN && ok && other == canonical {
+			return product, nil
+		}
+	}
+	return domain.Product{}, ErrNotFound
+}
+
+// retrieves the products in the trash
+func (r *repository) GetTrash() []domain.Product {
+	products, err := r.storage.GetTrash()
+	if err != nil {
+		return []domain.Product{}
+	}
+	return products
+}
+
+// search product in the trash by ID
+func (r *repository) GetTrashed(id int) (domain.Product, error) {
+	for _, product := range r.GetTrash() {
+		if product.ID == id {
+			return product, nil
+		}
+	}
+	return domain.Product{}, ErrNotInTrash
+}
+
+// moves a product to the trash, if it is at ifVersion when given
+func (r *repository) Trash(id int, ifVersion *int, actor string, deletedAt time.Time, purgeAt time.Time) error {
+	err := r.storage.TrashOne(id, ifVersion, actor, deletedAt, purgeAt)
+	switch {
+	case errors.Is(err, store.ErrVersionConflict):
+		return ErrVersionConflict
+	case err != nil:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// takes a product out of the trash
+func (r *repository) Restore(id int) (domain.Product, error) {
+	product, err := r.storage.RestoreOne(id)
+	if err != nil {
+		return domain.Product{}, ErrNotInTrash
+	}
+	return product, nil
+}
+
+// retrieves the revisions of a product, oldest first
+func (r *repository) GetRevisions(id int) []domain.Revision {
+	revisions, err := r.storage.GetRevisions(id)
+	if err != nil {
+		return []domain.Revision{}
+	}
+	return revisions
+}
+
+// returns when products were last written, the zero time when unknown
+func (r *repository) LastModified() time.Time {
+	modified, err := r.storage.LastModified()
+	if err != nil {
+		return time.Time{}
+	}
+	return modified
+}
+
+// deletes a product for good
 func (r *repository) Delete(id int) error {
 	err := r.storage.DeleteOne(id)
 	if err != nil {
@@ -105,11 +245,193 @@ func (r *repository) Delete(id int) error {
 	return nil
 }
 
-// updates a product
+// updates a product still at the version it was read at
 func (r *repository) Update(id int, p domain.Product) (domain.Product, error) {
-	err := r.storage.UpdateOne(p)
+	updated, err := r.storage.UpdateOne(p)
+	if errors.Is(err, store.ErrVersionConflict) {
+		return domain.Product{}, ErrVersionConflict
+	}
 	if err != nil {
 		return domain.Product{}, ErrUpdatingProduct
 	}
-	return p, nil
+	return updated, nil
+}
+
+// adds deltas to product quantities in a warehouse atomically, returning the
+// new total quantities
+func (r *repository) AdjustQuantities(warehouseID int, deltas map[int]int) (map[int]int, error) {
+	balances, err := r.storage.AdjustQuantities(warehouseID, deltas)
+	switch {
+	case err == nil:
+		return balances, nil
+	case errors.Is(err, store.ErrInsufficientStock):
+		return nil, err
+	case errors.Is(err, store.ErrNotFound):
+		return nil, ErrNotFound
+	default:
+		return nil, ErrAdjustingStock
+	}
+}
+
+// puts the stock of products back to the one in copies taken before it was
+// adjusted
+func (r *repository) RestoreQuantities(previous []domain.Product) error {
+	err := r.storage.RestoreQuantities(previous)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, store.ErrNotFound):
+		return ErrNotFound
+	default:
+		return ErrAdjustingStock
+	}
+}
+
+// moves units of a product between warehouses
+func (r *repository) TransferQuantity(id int, fromWarehouseID int, toWarehouseID int, quantity int) (domain.Product, error) {
+	product, err := r.storage.TransferQuantity(id, fromWarehouseID, toWarehouseID, quantity)
+	switch {
+	case err == nil:
+		return product, nil
+	case errors.Is(err, store.ErrInsufficientStock):
+		return domain.Product{}, err
+	case errors.Is(err, store.ErrNotFound):
+		return domain.Product{}, ErrNotFound
+	default:
+		return domain.Product{}, ErrAdjustingStock
+	}
+}
+
+// validates if any product, in the trash or not, has units in a warehouse
+func (r *repository) WarehouseInUse(warehouseID int) bool {
+	list, err := r.withTrash()
+	if err != nil {
+		return true
+	}
+	for _, product := range list {
+		if product.WarehouseQuantity(warehouseID) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// retrieves the variants of a product
+func (r *repository) GetVariants(parentID int) []domain.Product {
+	var variants = []domain.Product{}
+	for _, product := range r.GetAll() {
+		if product.ParentID == parentID {
+			variants = append(variants, product)
+		}
+	}
+	return variants
+}
+
+// validates if any bundle is made with a product
+func (r *repository) InBundle(id int) bool {
+	for _, product := range r.GetAll() {
+		for _, c := range product.Components {
+			if c.ProductID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validates if any product, in the trash or not, is filed under a category
+func (r *repository) CategoryInUse(categoryID int) bool {
+	list, err := r.withTrash()
+	if err != nil {
+		return true
+	}
+	for _, product := range list {
+		if product.CategoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// retrieves every product, the ones in the trash included, which can still
+// be restored along with what they refer to
+func (r *repository) withTrash() ([]domain.Product, error) {
+	live, err := r.storage.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := r.storage.GetTrash()
+	if err != nil {
+		return nil, err
+	}
+	return append(live, trashed...), nil
+}
+
+// validates if any product, in the trash or not, is priced in a currency
+func (r *repository) CurrencyInUse(currency string) bool {
+	list, err := r.withTrash()
+	if err != nil {
+		return true
+	}
+	for _, product := range list {
+		if strings.EqualFold(product.Currency, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// search products in any of the categories, when given, carrying every tag
+func (r *repository) SearchByCategoryAndTags(categoryIDs []int, tags []string) []domain.Product {
+	var filtered = []domain.Product{}
+	for _, product := range r.GetAll() {
+		if len(categoryIDs) > 0 && !containsInt(categoryIDs, product.CategoryID) {
+			continue
+		}
+		if !hasTags(product, tags) {
+			continue
+		}
+		filtered = append(filtered, product)
+	}
+	return filtered
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTags(product domain.Product, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range product.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// adds the units of a lot to a product
+func (r *repository) ReceiveLot(id int, warehouseID int, lot domain.Lot) (domain.Product, error) {
+	product, err := r.storage.ReceiveLot(id, warehouseID, lot)
+	switch {
+	case err == nil:
+		return product, nil
+	case errors.Is(err, domain.ErrLotExpiration):
+		return domain.Product{}, err
+	case errors.Is(err, store.ErrNotFound):
+		return domain.Product{}, ErrNotFound
+	default:
+		return domain.Product{}, ErrAdjustingStock
+	}
 }
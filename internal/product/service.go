@@ -4,61 +4,694 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/hernan-hdiaz/go-web/internal/alert"
+	"github.com/hernan-hdiaz/go-web/internal/category"
+	"github.com/hernan-hdiaz/go-web/internal/currency"
 	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/ledger"
+	"github.com/hernan-hdiaz/go-web/internal/pricelist"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
+	"github.com/hernan-hdiaz/go-web/pkg/web"
 )
 
 type Service interface {
 	Get(ctx context.Context, id int) (domain.Product, error)
 	GetAll(ctx context.Context) []domain.Product
+	LastModified(ctx context.Context) time.Time
 	SearchByPriceGt(ctx context.Context, priceGt float64) ([]domain.Product, error)
 	Save(ctx context.Context, productRequest domain.Product) (int, error)
-	Update(ctx context.Context, productRequest domain.ProductRequest, id int) (domain.Product, error)
-	Delete(ctx context.Context, id int) error
-	GetTotalPrice(ctx context.Context, productListIds []int) ([]domain.Product, float64, error)
+	Update(ctx context.Context, productRequest domain.ProductRequest, id int, ifMatch []int) (domain.Product, error)
+	Delete(ctx context.Context, id int, ifMatch []int) error
+	GetTotalPrice(ctx context.Context, productListIds []int, opts domain.PriceOptions) ([]domain.Product, float64, error)
+	InCurrency(ctx context.Context, products []domain.Product, currency string) ([]domain.Product, error)
+	Quote(ctx context.Context, lines []domain.LineRequest, opts domain.PriceOptions) (domain.Quote, error)
+	AdjustStock(ctx context.Context, deltas map[int]int, movement domain.Movement) error
+	LockStock() func()
+	Movements(ctx context.Context, id int) (domain.StockLedger, error)
+	RecordMovement(ctx context.Context, id int, movementRequest domain.MovementRequest) (domain.Movement, error)
+	LowStock(ctx context.Context) []domain.Product
+	Stock(ctx context.Context, id int) ([]domain.WarehouseStock, error)
+	Transfer(ctx context.Context, id int, transferRequest domain.TransferRequest) (domain.Product, error)
+	Lots(ctx context.Context, id int) ([]domain.Lot, error)
+	ReceiveLot(ctx context.Context, id int, lotRequest domain.LotRequest) (domain.Product, error)
+	Filter(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error)
+	Variants(ctx context.Context, id int) ([]domain.Product, error)
+	CreateVariant(ctx context.Context, parentID int, variantRequest domain.VariantRequest) (domain.Product, error)
+	CreateBundle(ctx context.Context, bundleRequest domain.BundleRequest) (domain.Product, error)
+	ReceiveAtCost(ctx context.Context, deltas map[int]int, costs map[int]float64, currency string, movement domain.Movement) error
+	Margins(ctx context.Context) []domain.Margin
+	Lookup(ctx context.Context, code string) (domain.Product, error)
+	Transition(ctx context.Context, id int, statusRequest domain.StatusRequest) (domain.Product, error)
+	Schedule(ctx context.Context, id int, scheduleRequest domain.ScheduleRequest) (domain.Product, error)
+	PublishDue(ctx context.Context) int
+	Trash(ctx context.Context) []domain.Product
+	Restore(ctx context.Context, id int) (domain.Product, error)
+	Purge(ctx context.Context, id int) error
+	PurgeDue(ctx context.Context) int
+	Revisions(ctx context.Context, id int) ([]domain.Revision, error)
+	Revision(ctx context.Context, id int, number int) (domain.Revision, error)
+	At(ctx context.Context, id int, at time.Time) (domain.Product, error)
+	Revert(ctx context.Context, id int, number int) (domain.Product, error)
+}
+
+const (
+	// SchedulerActor is recorded as the actor of scheduled status changes
+	SchedulerActor = "scheduler"
+	// TrashRetention is how long deleted products stay in the trash
+	TrashRetention = 30 * 24 * time.Hour
+)
+
+// StockHolder reports the units of each product held aside, which can not be
+// quoted or sold unless the holder with excludeID is the one buying them
+type StockHolder interface {
+	Held(excludeID int) map[int]int
 }
 
 type service struct {
-	repo Repository
+	repo       Repository
+	rates      currency.Service
+	prices     pricelist.Service
+	holds      StockHolder
+	ledger     ledger.Service
+	alerts     alert.Alerter
+	warehouses warehouse.Service
+	categories category.Service
+	stock      sync.Mutex
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+func NewService(repo Repository, rates currency.Service, prices pricelist.Service, holds StockHolder, ledger ledger.Service, alerts alert.Alerter, warehouses warehouse.Service, categories category.Service) Service {
+	return &service{repo: repo, rates: rates, prices: prices, holds: holds, ledger: ledger, alerts: alerts, warehouses: warehouses, categories: categories}
 }
-func (s *service) GetTotalPrice(ctx context.Context, productListIds []int) ([]domain.Product, float64, error) {
+
+// GetTotalPrice prices the given list of product ids, where a repeated id
+// means one more unit of that product. See Quote for how prices are computed.
+func (s *service) GetTotalPrice(ctx context.Context, productListIds []int, opts domain.PriceOptions) ([]domain.Product, float64, error) {
+	var lines = []domain.LineRequest{}
+	var lineIndex = map[int]int{}
+	for _, id := range productListIds {
+		if i, ok := lineIndex[id]; ok {
+			lines[i].Quantity++
+			continue
+		}
+		lineIndex[id] = len(lines)
+		lines = append(lines, domain.LineRequest{ProductID: id, Quantity: 1})
+	}
+	quote, err := s.Quote(ctx, lines, opts)
+	if err != nil {
+		return []domain.Product{}, 0, err
+	}
 	var productList = []domain.Product{}
-	var productQuantity = map[int]int{}
-	var totalPrice float64
 	for _, id := range productListIds {
 		product, err := s.Get(ctx, id)
 		if err != nil {
 			return []domain.Product{}, 0, err
 		}
-		if product.IsPublished {
-			if productQuantity[product.ID] == 0 && product.Quantity > 0 {
-				productQuantity[product.ID] = 1
-			} else if productQuantity[product.ID] < product.Quantity {
-				productQuantity[product.ID]++
-			} else {
-				return []domain.Product{}, 0, fmt.Errorf("unavailable quantity for product id: %d", product.ID)
+		product.Price = quote.Lines[lineIndex[id]].UnitPrice
+		product.Currency = quote.Currency
+		productList = append(productList, product)
+	}
+	return productList, quote.TotalPrice, nil
+}
+
+// Quote prices lines in opts.Currency (base currency when empty), checking
+// every product is published and has enough quantity not held by other
+// reservations than opts.ReservationID, and in opts.WarehouseID when set.
+// Lines are converted to base units and lines of the same product are
+// merged. When opts.CustomerGroup has a price list, each product is priced at
+// the quantity break its units reach, otherwise at its base price; packs with
+// their own price sell at it. Prices are converted from the product currency without rounding,
+// the tax tier is picked by the total number of units and amounts are rounded
+// to 2 decimals only when reported, so line prices may not add up to the
+// subtotal to the cent.
+func (s *service) Quote(ctx context.Context, lines []domain.LineRequest, opts domain.PriceOptions) (domain.Quote, error) {
+	currency, err := s.rates.Validate(ctx, opts.Currency)
+	if err != nil {
+		return domain.Quote{}, err
+	}
+	if opts.WarehouseID != 0 {
+		if _, err := s.warehouses.Get(ctx, opts.WarehouseID); err != nil {
+			return domain.Quote{}, err
+		}
+	}
+	var quote = domain.Quote{Currency: currency, Lines: []domain.PricedLine{}}
+	var lineIndex = map[int]int{}
+	var units int
+	//Units of packs with their own price, and what they add up to
+	var packedUnits = map[int]int{}
+	var packedPrice = map[int]float64{}
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return domain.Quote{}, ErrQuantityOutOfRange
+		}
+		quantity := line.Quantity
+		if line.Unit != "" {
+			product, err := s.repo.GetByID(line.ProductID)
+			if err != nil {
+				return domain.Quote{}, err
 			}
-			totalPrice += product.Price
-			productList = append(productList, product)
-		} else {
-			return []domain.Product{}, 0, fmt.Errorf("product not published id: %d", product.ID)
+			pack, err := product.Pack(line.Unit)
+			if err != nil {
+				return domain.Quote{}, err
+			}
+			quantity = line.Quantity * pack.Factor
+			if pack.Price > 0 {
+				packedUnits[line.ProductID] += quantity
+				packedPrice[line.ProductID] += pack.Price * float64(line.Quantity)
+			}
+		}
+		units += quantity
+		if i, ok := lineIndex[line.ProductID]; ok {
+			quote.Lines[i].Quantity += quantity
+			continue
 		}
+		lineIndex[line.ProductID] = len(quote.Lines)
+		quote.Lines = append(quote.Lines, domain.PricedLine{ProductID: line.ProductID, Quantity: quantity})
 	}
+	var subtotal float64
+	//Bundles take their units from their components
+	var held = s.expandBundles(s.holds.Held(opts.ReservationID))
+	var demand = map[int]int{}
+	for _, line := range quote.Lines {
+		demand[line.ProductID] += line.Quantity
+	}
+	demand = s.expandBundles(demand)
+	var checked = map[int]bool{}
+	for i, line := range quote.Lines {
+		product, err := s.Get(ctx, line.ProductID)
+		if err != nil {
+			return domain.Quote{}, err
+		}
+		if !product.IsPublished {
+			return domain.Quote{}, fmt.Errorf("product not published id: %d", product.ID)
+		}
+		var stocked = []int{product.ID}
+		if product.IsBundle() {
+			stocked = []int{}
+			for _, c := range product.Components {
+				stocked = append(stocked, c.ProductID)
+			}
+		}
+		for _, id := range stocked {
+			if checked[id] {
+				continue
+			}
+			checked[id] = true
+			unit, err := s.repo.GetByID(id)
+			if err != nil {
+				return domain.Quote{}, err
+			}
+			if demand[id] > unit.Quantity-held[id] {
+				return domain.Quote{}, fmt.Errorf("unavailable quantity for product id: %d", id)
+			}
+			if opts.WarehouseID != 0 && demand[id] > unit.WarehouseQuantity(opts.WarehouseID) {
+				return domain.Quote{}, fmt.Errorf("unavailable quantity for product id: %d in warehouse id: %d", id, opts.WarehouseID)
+			}
+		}
+		unitPrice, err := s.unitPrice(ctx, product, line.Quantity, opts.CustomerGroup, currency)
+		if err != nil {
+			return domain.Quote{}, err
+		}
+		price := unitPrice * float64(line.Quantity-packedUnits[product.ID])
+		if packedUnits[product.ID] > 0 {
+			packPrice, err := s.rates.Convert(ctx, packedPrice[product.ID], product.Currency, currency)
+			if err != nil {
+				return domain.Quote{}, err
+			}
+			price += packPrice
+			unitPrice = price / float64(line.Quantity)
+		}
+		subtotal += price
+		quote.Lines[i].Name = product.Name
+		quote.Lines[i].UnitPrice = roundFloat(unitPrice, 2)
+		quote.Lines[i].Price = roundFloat(price, 2)
+	}
+	quote.TaxRate = TaxRate(units)
+	quote.Subtotal = roundFloat(subtotal, 2)
+	quote.TotalPrice = roundFloat(subtotal*(1+quote.TaxRate), 2)
+	return quote, nil
+}
+
+// prices one unit of a product in currency. Bundles priced from components
+// add up their components at base price less the bundle discount.
+func (s *service) unitPrice(ctx context.Context, product domain.Product, quantity int, customerGroup string, currency string) (float64, error) {
+	if product.Pricing == domain.BundlePricingComponents {
+		var price float64
+		for _, c := range product.Components {
+			component, err := s.repo.GetByID(c.ProductID)
+			if err != nil {
+				return 0, err
+			}
+			componentPrice, err := s.rates.Convert(ctx, component.Price, component.Currency, currency)
+			if err != nil {
+				return 0, err
+			}
+			price += componentPrice * float64(c.Quantity)
+		}
+		return price * (1 - product.Discount), nil
+	}
+	unitPrice := product.Price
+	if customerGroup != "" {
+		if listPrice, ok := s.prices.UnitPrice(ctx, customerGroup, product.ID, quantity); ok {
+			unitPrice = listPrice
+		}
+	}
+	return s.rates.Convert(ctx, unitPrice, product.Currency, currency)
+}
+
+// replaces the units of bundles with the units of their components, keeping
+// other products as they are
+func (s *service) expandBundles(units map[int]int) map[int]int {
+	var expanded = map[int]int{}
+	for id, quantity := range units {
+		product, err := s.repo.GetByID(id)
+		if err != nil || !product.IsBundle() {
+			expanded[id] += quantity
+			continue
+		}
+		for _, c := range product.Components {
+			expanded[c.ProductID] += quantity * c.Quantity
+		}
+	}
+	return expanded
+}
+
+// TaxRate returns the tax tier applied to a purchase of the given units
+func TaxRate(units int) float64 {
 	switch {
-	case len(productList) <= 10:
-		totalPrice *= 1.21
-	case len(productList) > 10 && len(productList) <= 20:
-		totalPrice *= 1.17
+	case units <= 10:
+		return 0.21
+	case units > 10 && units <= 20:
+		return 0.17
 	default:
-		totalPrice *= 1.15
+		return 0.15
+	}
+}
+
+// LockStock serializes availability checks with the stock changes and holds
+// that depend on them. Callers must call the returned func to unlock.
+func (s *service) LockStock() func() {
+	s.stock.Lock()
+	return s.stock.Unlock
+}
+
+// AdjustStock adds each delta to its product quantity, all or nothing, and
+// records one movement per product using movement as a template for the
+// type, reason, reference and warehouse. Without a warehouse, units are added
+// to the default one and taken from any. Deltas of bundles are applied to
+// their components. Products crossing their reorder point raise a low stock
+// alert. When the movements can not be recorded the stock is put back as it
+// was, so callers must hold the stock lock.
+func (s *service) AdjustStock(ctx context.Context, deltas map[int]int, movement domain.Movement) error {
+	deltas = s.expandBundles(deltas)
+	if movement.WarehouseID != 0 {
+		if _, err := s.warehouses.Get(ctx, movement.WarehouseID); err != nil {
+			return err
+		}
+	}
+	var previous = []domain.Product{}
+	for id := range deltas {
+		product, err := s.repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		previous = append(previous, product)
+	}
+	balances, err := s.repo.AdjustQuantities(movement.WarehouseID, deltas)
+	if err != nil {
+		return err
+	}
+	var movements = []domain.Movement{}
+	for id, delta := range deltas {
+		movement.ProductID = id
+		movement.Quantity = delta
+		movement.Balance = balances[id]
+		movements = append(movements, movement)
 	}
+	sort.Slice(movements, func(i, j int) bool { return movements[i].ProductID < movements[j].ProductID })
+	if _, err = s.ledger.Record(ctx, movements); err != nil {
+		return s.undoStock(err, previous)
+	}
+	s.alertLowStock(ctx, deltas, balances)
+	return nil
+}
 
-	return productList, roundFloat(totalPrice, 2), nil
+// puts the stock of products back as it was in previous after its movements
+// could not be recorded, so the ledger keeps matching it, and returns err
+func (s *service) undoStock(err error, previous []domain.Product) error {
+	if restoreErr := s.repo.RestoreQuantities(previous); restoreErr != nil {
+		return fmt.Errorf("%w, and the stock could not be put back: %v", err, restoreErr)
+	}
+	return err
+}
+
+// ReceiveAtCost adds units like AdjustStock and moves the cost of each
+// product to the average of the units it had and the units received at
+// costs, given in currency. Callers must hold the stock lock.
+func (s *service) ReceiveAtCost(ctx context.Context, deltas map[int]int, costs map[int]float64, currency string, movement domain.Movement) error {
+	var averages = map[int]float64{}
+	for id, delta := range deltas {
+		product, err := s.repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if product.IsBundle() {
+			return ErrBundleStock
+		}
+		cost, err := s.rates.Convert(ctx, costs[id], currency, product.Currency)
+		if err != nil {
+			return err
+		}
+		//Units held before costs were tracked count at the new cost
+		held := float64(product.Quantity)
+		if product.Cost == 0 {
+			product.Cost = cost
+		}
+		averages[id] = roundFloat((product.Cost*held+cost*float64(delta))/(held+float64(delta)), 2)
+	}
+	if err := s.AdjustStock(ctx, deltas, movement); err != nil {
+		return err
+	}
+	for id, cost := range averages {
+		product, err := s.repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		product.Cost = cost
+		if _, err := s.repo.Update(id, product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Margins compares the price of every product with a known cost against it
+func (s *service) Margins(ctx context.Context) []domain.Margin {
+	var margins = []domain.Margin{}
+	for _, product := range s.repo.GetAll() {
+		if product.Cost <= 0 {
+			continue
+		}
+		margin := product.Price - product.Cost
+		margins = append(margins, domain.Margin{
+			ProductID:     product.ID,
+			Name:          product.Name,
+			Price:         product.Price,
+			Cost:          product.Cost,
+			Margin:        roundFloat(margin, 2),
+			MarginPercent: roundFloat(margin/product.Price*100, 2),
+		})
+	}
+	return margins
+}
+
+// alerts on products whose quantity went from above to at or below their
+// reorder point
+func (s *service) alertLowStock(ctx context.Context, deltas map[int]int, balances map[int]int) {
+	for id, balance := range balances {
+		product, err := s.repo.GetByID(id)
+		if err != nil || product.ReorderPoint <= 0 {
+			continue
+		}
+		if balance <= product.ReorderPoint && balance-deltas[id] > product.ReorderPoint {
+			s.alerts.LowStock(ctx, domain.StockAlert{
+				ProductID:       product.ID,
+				Name:            product.Name,
+				CodeValue:       product.CodeValue,
+				Quantity:        balance,
+				ReorderPoint:    product.ReorderPoint,
+				ReorderQuantity: product.ReorderQuantity,
+				RaisedAt:        time.Now().UTC(),
+			})
+		}
+	}
+}
+
+// LowStock lists products at or below their reorder point
+func (s *service) LowStock(ctx context.Context) []domain.Product {
+	return s.repo.SearchLowStock()
+}
+
+// Stock returns the units of a product in every warehouse
+func (s *service) Stock(ctx context.Context, id int) ([]domain.WarehouseStock, error) {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return []domain.WarehouseStock{}, err
+	}
+	var stock = []domain.WarehouseStock{}
+	for _, w := range s.warehouses.GetAll(ctx) {
+		stock = append(stock, domain.WarehouseStock{WarehouseID: w.ID, Quantity: product.WarehouseQuantity(w.ID)})
+	}
+	return stock, nil
+}
+
+// Transfer moves units of a product between two warehouses, recording the
+// units leaving and entering as transfer movements
+func (s *service) Transfer(ctx context.Context, id int, transferRequest domain.TransferRequest) (domain.Product, error) {
+	if transferRequest.Quantity <= 0 {
+		return domain.Product{}, ErrQuantityOutOfRange
+	}
+	if transferRequest.FromWarehouseID == transferRequest.ToWarehouseID {
+		return domain.Product{}, ErrSameWarehouse
+	}
+	for _, warehouseID := range []int{transferRequest.FromWarehouseID, transferRequest.ToWarehouseID} {
+		if _, err := s.warehouses.Get(ctx, warehouseID); err != nil {
+			return domain.Product{}, err
+		}
+	}
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	if err := s.notBundle(id); err != nil {
+		return domain.Product{}, err
+	}
+	previous, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	product, err := s.repo.TransferQuantity(id, transferRequest.FromWarehouseID, transferRequest.ToWarehouseID, transferRequest.Quantity)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	movement := domain.Movement{
+		ProductID: id,
+		Type:      domain.MovementTransfer,
+		Balance:   product.Quantity,
+		Reason:    transferRequest.Reason,
+	}
+	out, in := movement, movement
+	out.WarehouseID, out.Quantity = transferRequest.FromWarehouseID, -transferRequest.Quantity
+	in.WarehouseID, in.Quantity = transferRequest.ToWarehouseID, transferRequest.Quantity
+	if _, err := s.ledger.Record(ctx, []domain.Movement{out, in}); err != nil {
+		return domain.Product{}, s.undoStock(err, []domain.Product{previous})
+	}
+	return product, nil
+}
+
+// Filter lists products under a category or its subcategories, carrying
+// every given tag and named, in the requested locale, like the given name
+func (s *service) Filter(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	var categoryIDs []int
+	if filter.CategoryID != 0 {
+		ids, err := s.categories.Descendants(ctx, filter.CategoryID)
+		if err != nil {
+			return []domain.Product{}, err
+		}
+		categoryIDs = ids
+	}
+	products := s.repo.SearchByCategoryAndTags(categoryIDs, normalizeTags(filter.Tags))
+	name := strings.ToLower(strings.TrimSpace(filter.Name))
+	if name == "" {
+		return products, nil
+	}
+	var named = []domain.Product{}
+	for _, product := range products {
+		localized := product
+		localized.Localize(filter.Locales)
+		if strings.Contains(strings.ToLower(localized.Name), name) {
+			named = append(named, product)
+		}
+	}
+	return named, nil
+}
+
+// Lookup finds a product by code value. GTINs match whatever number of
+// leading zeros they are written with.
+func (s *service) Lookup(ctx context.Context, code string) (domain.Product, error) {
+	product, err := s.repo.GetByCode(code)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return s.Get(ctx, product.ID)
+}
+
+// normalizes a code value and checks it against the code format of the
+// category, if any
+func (s *service) formatCode(ctx context.Context, code string, categoryID int) (string, error) {
+	code = gtin.Normalize(code)
+	if categoryID == 0 {
+		return code, nil
+	}
+	format, err := s.categories.CodeFormat(ctx, categoryID)
+	if err != nil || format == "" {
+		return code, err
+	}
+	return gtin.Format(code, format)
+}
+
+// checks pack sizes hold more than one base unit, at a price that is not
+// negative, under units of their own
+func validatePackSizes(baseUnit string, packs []domain.PackSize) error {
+	var seen = map[string]bool{baseUnit: true}
+	for _, pack := range packs {
+		if pack.Unit == "" || seen[pack.Unit] || pack.Factor <= 1 || pack.Price < 0 {
+			return ErrPackSize
+		}
+		seen[pack.Unit] = true
+	}
+	return nil
+}
+
+// normalizes the locales of translations, which need a name or description
+func validateTranslations(translations map[string]domain.Translation) (map[string]domain.Translation, error) {
+	if len(translations) == 0 {
+		return nil, nil
+	}
+	var normalized = map[string]domain.Translation{}
+	for locale, translation := range translations {
+		locale = domain.NormalizeLocale(locale)
+		if !domain.ValidLocale(locale) || (translation.Name == "" && translation.Description == "") {
+			return nil, ErrTranslation
+		}
+		if _, ok := normalized[locale]; ok {
+			return nil, ErrTranslation
+		}
+		normalized[locale] = translation
+	}
+	return normalized, nil
+}
+
+// lowercases and trims tags, dropping empty and repeated ones
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Lots returns the lots of a product, earliest expiration first
+func (s *service) Lots(ctx context.Context, id int) ([]domain.Lot, error) {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return []domain.Lot{}, err
+	}
+	if product.Lots == nil {
+		return []domain.Lot{}, nil
+	}
+	return product.Lots, nil
+}
+
+// ReceiveLot adds a delivery to a product as a receipt movement. The first
+// lot received turns the units the product already had into an initial lot
+// with the product expiration.
+func (s *service) ReceiveLot(ctx context.Context, id int, lotRequest domain.LotRequest) (domain.Product, error) {
+	if lotRequest.Quantity <= 0 {
+		return domain.Product{}, ErrQuantityOutOfRange
+	}
+	date, _ := time.Parse("02/01/2006", lotRequest.Expiration)
+	//Set minimum date
+	minimum_date, _ := time.Parse("02/01/2006", "01/01/2023")
+	//Check date restraints
+	if date.Before(minimum_date) {
+		return domain.Product{}, ErrDateOutOfRange
+	}
+	if lotRequest.WarehouseID != 0 {
+		if _, err := s.warehouses.Get(ctx, lotRequest.WarehouseID); err != nil {
+			return domain.Product{}, err
+		}
+	}
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	if err := s.notBundle(id); err != nil {
+		return domain.Product{}, err
+	}
+	previous, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	product, err := s.repo.ReceiveLot(id, lotRequest.WarehouseID, domain.Lot{
+		Code:       lotRequest.Code,
+		Quantity:   lotRequest.Quantity,
+		Expiration: lotRequest.Expiration,
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+	_, err = s.ledger.Record(ctx, []domain.Movement{{
+		ProductID:   id,
+		WarehouseID: lotRequest.WarehouseID,
+		Type:        domain.MovementReceipt,
+		Quantity:    lotRequest.Quantity,
+		Balance:     product.Quantity,
+		Lot:         lotRequest.Code,
+		Reason:      lotRequest.Reason,
+	}})
+	if err != nil {
+		return domain.Product{}, s.undoStock(err, []domain.Product{previous})
+	}
+	return product, nil
+}
+
+// Movements returns the stock ledger of a product
+func (s *service) Movements(ctx context.Context, id int) (domain.StockLedger, error) {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.StockLedger{}, err
+	}
+	return s.ledger.Reconcile(ctx, product), nil
+}
+
+// RecordMovement applies a receipt, adjustment or write-off to a product,
+// counted in the unit of the request and recorded in base units
+func (s *service) RecordMovement(ctx context.Context, id int, movementRequest domain.MovementRequest) (domain.Movement, error) {
+	if err := ledger.ValidateRequest(movementRequest); err != nil {
+		return domain.Movement{}, err
+	}
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	if err := s.notBundle(id); err != nil {
+		return domain.Movement{}, err
+	}
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Movement{}, err
+	}
+	quantity, err := product.ToBase(movementRequest.Unit, movementRequest.Quantity)
+	if err != nil {
+		return domain.Movement{}, err
+	}
+	err = s.AdjustStock(ctx, map[int]int{id: quantity}, domain.Movement{
+		Type:        movementRequest.Type,
+		Reason:      movementRequest.Reason,
+		WarehouseID: movementRequest.WarehouseID,
+	})
+	if err != nil {
+		return domain.Movement{}, err
+	}
+	movements := s.ledger.GetByProduct(ctx, id)
+	return movements[len(movements)-1], nil
 }
 
 func roundFloat(val float64, precision uint) float64 {
@@ -66,25 +699,203 @@ func roundFloat(val float64, precision uint) float64 {
 	return math.Round(val*ratio) / ratio
 }
 
+// InCurrency returns a copy of products with prices converted to currency,
+// each rounded half away from zero to 2 decimals
+func (s *service) InCurrency(ctx context.Context, products []domain.Product, currency string) ([]domain.Product, error) {
+	currency, err := s.rates.Validate(ctx, currency)
+	if err != nil {
+		return []domain.Product{}, err
+	}
+	converted := make([]domain.Product, 0, len(products))
+	for _, product := range products {
+		price, err := s.rates.Convert(ctx, product.Price, product.Currency, currency)
+		if err != nil {
+			return []domain.Product{}, err
+		}
+		product.Price = roundFloat(price, 2)
+		product.Currency = currency
+		converted = append(converted, product)
+	}
+	return converted, nil
+}
+
 func (s *service) Get(ctx context.Context, id int) (domain.Product, error) {
 	product, err := s.repo.GetByID(id)
 	if err != nil {
 		return domain.Product{}, err
 	}
+	product.Variants = summarizeVariants(s.repo.GetVariants(id))
+	if product.IsBundle() {
+		var components = map[int]domain.Product{}
+		for _, c := range product.Components {
+			components[c.ProductID], _ = s.repo.GetByID(c.ProductID)
+		}
+		product.DeriveFromComponents(components)
+		s.priceBundle(ctx, &product)
+	}
 	return product, nil
 }
 
+// LastModified returns when any product was last written, the zero time when
+// unknown. Reads made after it never miss a write made before it.
+func (s *service) LastModified(ctx context.Context) time.Time {
+	return s.repo.LastModified()
+}
+
 func (s *service) GetAll(ctx context.Context) []domain.Product {
 	products := s.repo.GetAll()
+	variants := map[int][]domain.Product{}
+	for _, p := range products {
+		if p.ParentID != 0 {
+			variants[p.ParentID] = append(variants[p.ParentID], p)
+		}
+	}
+	var byID = map[int]domain.Product{}
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	for i := range products {
+		products[i].Variants = summarizeVariants(variants[products[i].ID])
+		if products[i].IsBundle() {
+			products[i].DeriveFromComponents(byID)
+			s.priceBundle(ctx, &products[i])
+		}
+	}
 	return products
 }
 
+// Variants lists the variants of a product
+func (s *service) Variants(ctx context.Context, id int) ([]domain.Product, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return []domain.Product{}, err
+	}
+	return s.repo.GetVariants(id), nil
+}
+
+// CreateVariant saves a new product under parentID, sharing its name,
+// category, tags and currency
+func (s *service) CreateVariant(ctx context.Context, parentID int, variantRequest domain.VariantRequest) (domain.Product, error) {
+	variant := domain.Product{
+		ParentID:    parentID,
+		Quantity:    variantRequest.Quantity,
+		CodeValue:   variantRequest.CodeValue,
+		IsPublished: variantRequest.IsPublished,
+		Expiration:  variantRequest.Expiration,
+		Price:       variantRequest.Price,
+		Attributes:  variantRequest.Attributes,
+	}
+	id, err := s.Save(ctx, variant)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// CreateBundle saves a product made of other products. Bundles hold no stock,
+// they are available as long as their components are.
+func (s *service) CreateBundle(ctx context.Context, bundleRequest domain.BundleRequest) (domain.Product, error) {
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	switch bundleRequest.Pricing {
+	case domain.BundlePricingFixed:
+		if bundleRequest.Price <= 0 {
+			return domain.Product{}, ErrPriceOutOfRange
+		}
+	case domain.BundlePricingComponents:
+		if bundleRequest.Discount < 0 || bundleRequest.Discount >= 1 {
+			return domain.Product{}, ErrDiscountOutOfRange
+		}
+		bundleRequest.Price = 0
+	}
+	bundle := domain.Product{
+		Name:        bundleRequest.Name,
+		CodeValue:   gtin.Normalize(bundleRequest.CodeValue),
+		IsPublished: bundleRequest.IsPublished,
+		Price:       bundleRequest.Price,
+		Pricing:     bundleRequest.Pricing,
+		Discount:    bundleRequest.Discount,
+	}
+	if bundleRequest.Currency != "" {
+		unlock := s.rates.LockRates()
+		defer unlock()
+		code, err := s.rates.Validate(ctx, bundleRequest.Currency)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		bundle.Currency = code
+	}
+	//Repeated components are merged
+	var index = map[int]int{}
+	for _, c := range bundleRequest.Components {
+		if c.Quantity <= 0 {
+			return domain.Product{}, ErrQuantityOutOfRange
+		}
+		component, err := s.repo.GetByID(c.ProductID)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		if component.IsBundle() {
+			return domain.Product{}, ErrNestedBundle
+		}
+		if i, ok := index[c.ProductID]; ok {
+			bundle.Components[i].Quantity += c.Quantity
+			continue
+		}
+		index[c.ProductID] = len(bundle.Components)
+		bundle.Components = append(bundle.Components, c)
+	}
+	id, err := s.repo.Create(bundle)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return s.Get(ctx, id)
+}
+
+// sets the price of a bundle priced from components, in the bundle currency
+func (s *service) priceBundle(ctx context.Context, bundle *domain.Product) {
+	if bundle.Pricing != domain.BundlePricingComponents {
+		return
+	}
+	if price, err := s.unitPrice(ctx, *bundle, 1, "", bundle.Currency); err == nil {
+		bundle.Price = roundFloat(price, 2)
+	}
+}
+
+// fails for bundles, whose stock can only change through their components
+func (s *service) notBundle(id int) error {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if product.IsBundle() {
+		return ErrBundleStock
+	}
+	return nil
+}
+
+// aggregates the stock and price range of variants, nil without variants
+func summarizeVariants(variants []domain.Product) *domain.VariantSummary {
+	if len(variants) == 0 {
+		return nil
+	}
+	summary := &domain.VariantSummary{MinPrice: variants[0].Price, MaxPrice: variants[0].Price}
+	for _, v := range variants {
+		summary.Count++
+		summary.Quantity += v.Quantity
+		summary.MinPrice = math.Min(summary.MinPrice, v.Price)
+		summary.MaxPrice = math.Max(summary.MaxPrice, v.Price)
+	}
+	return summary
+}
+
 func (s *service) SearchByPriceGt(ctx context.Context, priceGt float64) ([]domain.Product, error) {
 	products := s.repo.SearchPriceGt(priceGt)
 	return products, nil
 }
 
 func (s *service) Save(ctx context.Context, productRequest domain.Product) (int, error) {
+	s.stock.Lock()
+	defer s.stock.Unlock()
 	date, _ := time.Parse("02/01/2006", productRequest.Expiration)
 	//Set minimum date
 	minimum_date, _ := time.Parse("02/01/2006", "01/01/2023")
@@ -98,31 +909,127 @@ func (s *service) Save(ctx context.Context, productRequest domain.Product) (int,
 	if productRequest.Quantity <= 0 {
 		return 0, ErrQuantityOutOfRange
 	}
+	if productRequest.ReorderPoint < 0 || productRequest.ReorderQuantity < 0 {
+		return 0, ErrReorderOutOfRange
+	}
+	if productRequest.Currency != "" {
+		unlock := s.rates.LockRates()
+		defer unlock()
+		code, err := s.rates.Validate(ctx, productRequest.Currency)
+		if err != nil {
+			return 0, err
+		}
+		productRequest.Currency = code
+	}
+	//Variants take the shared fields from their parent
+	if productRequest.ParentID != 0 {
+		parent, err := s.repo.GetByID(productRequest.ParentID)
+		if err != nil {
+			return 0, err
+		}
+		if parent.ParentID != 0 {
+			return 0, ErrNestedVariant
+		}
+		productRequest.Inherit(parent)
+	}
+	if productRequest.CategoryID != 0 {
+		if _, err := s.categories.Get(ctx, productRequest.CategoryID); err != nil {
+			return 0, err
+		}
+	}
+	productRequest.Tags = normalizeTags(productRequest.Tags)
+	productRequest.Variants = nil
+	//Bundles are only made through CreateBundle, which checks their components
+	productRequest.Components = nil
+	productRequest.Pricing = ""
+	productRequest.Discount = 0
+	//New stock is all in the default warehouse and, until lots are received,
+	//expires on the product's expiration date
+	productRequest.Stock = nil
+	productRequest.Lots = nil
+	//Only receipts set the average cost
+	productRequest.Cost = 0
+	//New products start published or draft as is_published says
+	productRequest.Status = ""
+	productRequest.StatusHistory = nil
+	productRequest.PublishAt = nil
+	productRequest.UnpublishAt = nil
+	//New products are never in the trash
+	productRequest.DeletedAt = nil
+	productRequest.DeletedBy = ""
+	productRequest.PurgeAt = nil
+	translations, err := validateTranslations(productRequest.Translations)
+	if err != nil {
+		return 0, err
+	}
+	productRequest.Translations = translations
+	code, err := s.formatCode(ctx, productRequest.CodeValue, productRequest.CategoryID)
+	if err != nil {
+		return 0, err
+	}
+	productRequest.CodeValue = code
+	if err := validatePackSizes(productRequest.Unit(), productRequest.PackSizes); err != nil {
+		return 0, err
+	}
 
 	productID, err := s.repo.Create(productRequest)
 	if err != nil {
 		return 0, err
 	}
+	_, err = s.ledger.Record(ctx, []domain.Movement{{
+		ProductID: productID,
+		Type:      domain.MovementReceipt,
+		Quantity:  productRequest.Quantity,
+		Balance:   productRequest.Quantity,
+		Reason:    "initial stock",
+	}})
+	if err != nil {
+		//A product without its initial stock in the ledger is not created
+		if deleteErr := s.repo.Delete(productID); deleteErr != nil {
+			return 0, fmt.Errorf("%w, and the product could not be removed: %v", err, deleteErr)
+		}
+		return 0, err
+	}
 	return productID, nil
 }
 
-func (s *service) Update(ctx context.Context, productRequest domain.ProductRequest, id int) (domain.Product, error) {
+// Update changes the given fields of a product. When ifMatch is not nil the
+// product must still be at one of its versions.
+func (s *service) Update(ctx context.Context, productRequest domain.ProductRequest, id int, ifMatch []int) (domain.Product, error) {
+	s.stock.Lock()
+	defer s.stock.Unlock()
 	product, err := s.repo.GetByID(id)
 	if err != nil {
 		return domain.Product{}, err
 	}
+	if !versionMatches(product, ifMatch) {
+		return domain.Product{}, ErrVersionConflict
+	}
+	if product.ParentID != 0 && changesShared(product, productRequest) {
+		return domain.Product{}, ErrSharedWithParent
+	}
+	if product.IsBundle() && productRequest.Quantity > 0 {
+		return domain.Product{}, ErrBundleStock
+	}
+	previousCategoryID := product.CategoryID
 	if productRequest.Name != "" {
 		product.Name = productRequest.Name
 	}
-	if productRequest.CodeValue != "" && productRequest.CodeValue != product.CodeValue {
-		validation := s.repo.ValidateCodeValue(productRequest.CodeValue)
-		if !validation {
-			return domain.Product{}, ErrAlreadyExists
+	if productRequest.Description != "" {
+		product.Description = productRequest.Description
+	}
+	//An empty translation list clears the translations
+	if productRequest.Translations != nil {
+		translations, err := validateTranslations(productRequest.Translations)
+		if err != nil {
+			return domain.Product{}, err
 		}
-
-		product.CodeValue = productRequest.CodeValue
+		product.Translations = translations
 	}
-	if productRequest.Expiration != "" {
+	if productRequest.Expiration != "" && productRequest.Expiration != product.Expiration {
+		if len(product.Lots) > 0 {
+			return domain.Product{}, ErrExpirationFromLots
+		}
 		date, _ := time.Parse("02/01/2006", productRequest.Expiration)
 		//Set minimum date
 		minimum_date, _ := time.Parse("02/01/2006", "01/01/2023")
@@ -132,23 +1039,443 @@ func (s *service) Update(ctx context.Context, productRequest domain.ProductReque
 		}
 		product.Expiration = productRequest.Expiration
 	}
-	if productRequest.Quantity > 0 {
-		product.Quantity = productRequest.Quantity
-	}
 	if productRequest.Price > 0 {
 		product.Price = productRequest.Price
 	}
-	if productRequest.IsPublished != nil {
-		product.IsPublished = *productRequest.IsPublished
+	//is_published follows the status, which only changes through Transition,
+	//so a product read and sent back whole is updated all the same
+	if productRequest.ReorderPoint != nil {
+		if *productRequest.ReorderPoint < 0 {
+			return domain.Product{}, ErrReorderOutOfRange
+		}
+		product.ReorderPoint = *productRequest.ReorderPoint
+	}
+	if productRequest.ReorderQuantity != nil {
+		if *productRequest.ReorderQuantity < 0 {
+			return domain.Product{}, ErrReorderOutOfRange
+		}
+		product.ReorderQuantity = *productRequest.ReorderQuantity
+	}
+	if productRequest.Currency != "" {
+		unlock := s.rates.LockRates()
+		defer unlock()
+		code, err := s.rates.Validate(ctx, productRequest.Currency)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		product.Currency = code
+	}
+	if productRequest.CategoryID != nil {
+		if *productRequest.CategoryID != 0 {
+			if _, err := s.categories.Get(ctx, *productRequest.CategoryID); err != nil {
+				return domain.Product{}, err
+			}
+		}
+		product.CategoryID = *productRequest.CategoryID
+	}
+	//An empty tag list clears the tags
+	if productRequest.Tags != nil {
+		product.Tags = normalizeTags(productRequest.Tags)
+	}
+	//Codes follow the format of the category, which may have just changed
+	if productRequest.CodeValue != "" || product.CategoryID != previousCategoryID {
+		code := product.CodeValue
+		if productRequest.CodeValue != "" {
+			code = productRequest.CodeValue
+		}
+		code, err = s.formatCode(ctx, code, product.CategoryID)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		if code != product.CodeValue {
+			validation := s.repo.ValidateCodeValue(code)
+			if !validation {
+				return domain.Product{}, ErrAlreadyExists
+			}
+			product.CodeValue = code
+		}
+	}
+	if productRequest.Attributes != nil {
+		product.Attributes = productRequest.Attributes
+	}
+	if productRequest.BaseUnit != "" {
+		product.BaseUnit = productRequest.BaseUnit
+	}
+	//An empty pack size list clears the pack sizes
+	if productRequest.PackSizes != nil {
+		product.PackSizes = productRequest.PackSizes
+	}
+	if err := validatePackSizes(product.Unit(), product.PackSizes); err != nil {
+		return domain.Product{}, err
 	}
 	product, err = s.repo.Update(id, product)
 	if err != nil {
 		return domain.Product{}, err
 	}
+	if err := s.updateVariants(product); err != nil {
+		return domain.Product{}, err
+	}
+	//Quantity changes go through the ledger as an adjustment
+	if productRequest.Quantity > 0 && productRequest.Quantity != product.Quantity {
+		delta := productRequest.Quantity - product.Quantity
+		err = s.AdjustStock(ctx, map[int]int{id: delta}, domain.Movement{
+			Type:   domain.MovementAdjustment,
+			Reason: "quantity set by update",
+		})
+		if err != nil {
+			return domain.Product{}, err
+		}
+		//Stock and lots were updated by the adjustment
+		return s.repo.GetByID(id)
+	}
 	return product, nil
 }
 
-func (s *service) Delete(ctx context.Context, id int) error {
-	err := s.repo.Delete(id)
-	return err
+// Transition moves a product to another publishing status, if the current
+// one allows it. Leaving a status drops the schedule that no longer applies.
+func (s *service) Transition(ctx context.Context, id int, statusRequest domain.StatusRequest) (domain.Product, error) {
+	if !domain.ValidProductStatus(statusRequest.Status) {
+		return domain.Product{}, ErrInvalidStatus
+	}
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if !domain.CanTransition(product.State(), statusRequest.Status) {
+		return domain.Product{}, ErrTransition
+	}
+	product.SetState(statusRequest.Status, statusRequest.Reason, web.Actor(ctx), time.Now().UTC())
+	clearSchedule(&product)
+	return s.repo.Update(id, product)
+}
+
+// Schedule sets when a product in review is published and when a product in
+// review or published is archived
+func (s *service) Schedule(ctx context.Context, id int, scheduleRequest domain.ScheduleRequest) (domain.Product, error) {
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	state := product.State()
+	switch {
+	case scheduleRequest.PublishAt != nil && state != domain.ProductStatusInReview:
+		return domain.Product{}, ErrSchedule
+	case scheduleRequest.UnpublishAt != nil && state != domain.ProductStatusInReview && state != domain.ProductStatusPublished:
+		return domain.Product{}, ErrSchedule
+	case scheduleRequest.PublishAt != nil && scheduleRequest.UnpublishAt != nil && !scheduleRequest.UnpublishAt.After(*scheduleRequest.PublishAt):
+		return domain.Product{}, ErrSchedule
+	}
+	product.PublishAt = scheduleRequest.PublishAt
+	product.UnpublishAt = scheduleRequest.UnpublishAt
+	return s.repo.Update(id, product)
+}
+
+// PublishDue publishes products in review whose publish time passed and
+// archives published products whose unpublish time passed, returning how
+// many were updated
+func (s *service) PublishDue(ctx context.Context) int {
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	now := time.Now().UTC()
+	updated := 0
+	for _, product := range s.repo.GetAll() {
+		state := product.State()
+		switch {
+		case state == domain.ProductStatusInReview && product.PublishAt != nil && !product.PublishAt.After(now):
+			product.SetState(domain.ProductStatusPublished, "scheduled publish", SchedulerActor, now)
+			product.PublishAt = nil
+			//Both times may have passed since the last run
+			if product.UnpublishAt != nil && !product.UnpublishAt.After(now) {
+				product.SetState(domain.ProductStatusArchived, "scheduled unpublish", SchedulerActor, now)
+				product.UnpublishAt = nil
+			}
+		case state == domain.ProductStatusPublished && product.UnpublishAt != nil && !product.UnpublishAt.After(now):
+			product.SetState(domain.ProductStatusArchived, "scheduled unpublish", SchedulerActor, now)
+			product.UnpublishAt = nil
+		default:
+			continue
+		}
+		if _, err := s.repo.Update(product.ID, product); err == nil {
+			updated++
+		}
+	}
+	return updated
+}
+
+// drops schedule times that do not apply to the status of a product
+func clearSchedule(product *domain.Product) {
+	state := product.State()
+	if state != domain.ProductStatusInReview {
+		product.PublishAt = nil
+	}
+	if state != domain.ProductStatusInReview && state != domain.ProductStatusPublished {
+		product.UnpublishAt = nil
+	}
+}
+
+// copies the shared fields of a product down to its variants
+func (s *service) updateVariants(product domain.Product) error {
+	for _, variant := range s.repo.GetVariants(product.ID) {
+		variant.Inherit(product)
+		if _, err := s.repo.Update(variant.ID, variant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revisions lists the revisions of a product, in the trash or not, each with
+// the fields it changed from the one before
+func (s *service) Revisions(ctx context.Context, id int) ([]domain.Revision, error) {
+	if err := s.exists(id); err != nil {
+		return []domain.Revision{}, err
+	}
+	revisions := s.repo.GetRevisions(id)
+	for i := range revisions {
+		if i > 0 {
+			revisions[i].Changes = domain.Diff(*revisions[i-1].Product, *revisions[i].Product)
+		}
+	}
+	for i := range revisions {
+		revisions[i].Product = nil
+	}
+	return revisions, nil
+}
+
+// Revision returns a product as it was written in a revision, with the
+// fields it changed from the one before
+func (s *service) Revision(ctx context.Context, id int, number int) (domain.Revision, error) {
+	if err := s.exists(id); err != nil {
+		return domain.Revision{}, err
+	}
+	revisions := s.repo.GetRevisions(id)
+	for i, revision := range revisions {
+		if revision.Number != number {
+			continue
+		}
+		if i > 0 {
+			revision.Changes = domain.Diff(*revisions[i-1].Product, *revision.Product)
+		}
+		return revision, nil
+	}
+	return domain.Revision{}, ErrRevisionNotFound
+}
+
+// At returns a product as it was at the given time
+func (s *service) At(ctx context.Context, id int, at time.Time) (domain.Product, error) {
+	if err := s.exists(id); err != nil {
+		return domain.Product{}, err
+	}
+	var found *domain.Product
+	for _, revision := range s.repo.GetRevisions(id) {
+		if revision.CreatedAt.After(at) {
+			break
+		}
+		found = revision.Product
+	}
+	if found == nil {
+		return domain.Product{}, ErrRevisionNotFound
+	}
+	return *found, nil
+}
+
+// Revert writes a prior revision of a product back as a new revision. Stock,
+// cost, bundle components and the publishing status keep following their own
+// workflows and variants keep the fields they share with their parent.
+func (s *service) Revert(ctx context.Context, id int, number int) (domain.Product, error) {
+	revision, err := s.Revision(ctx, id, number)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	reverted := *revision.Product
+	reverted.ID = current.ID
+	reverted.Version = current.Version
+	reverted.Quantity = current.Quantity
+	reverted.Stock = current.Stock
+	reverted.Lots = current.Lots
+	reverted.Cost = current.Cost
+	reverted.Components = current.Components
+	reverted.Pricing = current.Pricing
+	reverted.Discount = current.Discount
+	reverted.IsPublished = current.IsPublished
+	reverted.Status = current.Status
+	reverted.StatusHistory = current.StatusHistory
+	reverted.PublishAt = current.PublishAt
+	reverted.UnpublishAt = current.UnpublishAt
+	reverted.ParentID = current.ParentID
+	if current.ParentID != 0 {
+		parent, err := s.repo.GetByID(current.ParentID)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		reverted.Inherit(parent)
+	}
+	//Lots decide the expiration of products that have them
+	if len(current.Lots) > 0 {
+		reverted.Expiration = current.Expiration
+	}
+	if reverted.CategoryID != 0 {
+		if _, err := s.categories.Get(ctx, reverted.CategoryID); err != nil {
+			return domain.Product{}, err
+		}
+	}
+	if reverted.Currency != "" {
+		unlock := s.rates.LockRates()
+		defer unlock()
+		if _, err := s.rates.Validate(ctx, reverted.Currency); err != nil {
+			return domain.Product{}, err
+		}
+	}
+	code, err := s.formatCode(ctx, reverted.CodeValue, reverted.CategoryID)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if code != current.CodeValue && !s.repo.ValidateCodeValue(code) {
+		return domain.Product{}, ErrAlreadyExists
+	}
+	reverted.CodeValue = code
+	if err := validatePackSizes(reverted.Unit(), reverted.PackSizes); err != nil {
+		return domain.Product{}, err
+	}
+	product, err := s.repo.Update(id, reverted)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if err := s.updateVariants(product); err != nil {
+		return domain.Product{}, err
+	}
+	return product, nil
+}
+
+// checks a product exists, in the trash or not
+func (s *service) exists(id int) error {
+	if _, err := s.repo.GetByID(id); err == nil {
+		return nil
+	}
+	if _, err := s.repo.GetTrashed(id); err == nil {
+		return nil
+	}
+	return ErrNotFound
+}
+
+// reports whether a request changes a field a variant shares with its parent
+func changesShared(variant domain.Product, productRequest domain.ProductRequest) bool {
+	switch {
+	case productRequest.Name != "" && productRequest.Name != variant.Name:
+		return true
+	case productRequest.Description != "" && productRequest.Description != variant.Description:
+		return true
+	case productRequest.Translations != nil:
+		translations, err := validateTranslations(productRequest.Translations)
+		return err != nil || !reflect.DeepEqual(translations, variant.Translations)
+	case productRequest.CategoryID != nil && *productRequest.CategoryID != variant.CategoryID:
+		return true
+	case productRequest.Tags != nil && !reflect.DeepEqual(normalizeTags(productRequest.Tags), variant.Tags):
+		return true
+	case productRequest.Currency != "" && !strings.EqualFold(productRequest.Currency, variant.Currency):
+		return true
+	default:
+		return false
+	}
+}
+
+// Delete moves a product to the trash, where it can be restored until it is
+// purged. When ifMatch is not nil the product must still be at one of its
+// versions. It holds the stock lock, as creating variants and bundles does,
+// so no variant or bundle can be added to it while it is checked.
+func (s *service) Delete(ctx context.Context, id int, ifMatch []int) error {
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !versionMatches(product, ifMatch) {
+		return ErrVersionConflict
+	}
+	if len(s.repo.GetVariants(id)) > 0 {
+		return ErrHasVariants
+	}
+	if s.repo.InBundle(id) {
+		return ErrInBundle
+	}
+	now := time.Now().UTC()
+	return s.repo.Trash(id, &product.Version, web.Actor(ctx), now, now.Add(TrashRetention))
+}
+
+// reports whether a product is at one of the versions, or any when nil
+func versionMatches(product domain.Product, versions []int) bool {
+	if versions == nil {
+		return true
+	}
+	for _, version := range versions {
+		if version == product.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// Trash lists the deleted products waiting to be purged
+func (s *service) Trash(ctx context.Context) []domain.Product {
+	return s.repo.GetTrash()
+}
+
+// Restore takes a product out of the trash. Variants and bundles need their
+// parent and components restored first.
+func (s *service) Restore(ctx context.Context, id int) (domain.Product, error) {
+	s.stock.Lock()
+	defer s.stock.Unlock()
+	product, err := s.repo.GetTrashed(id)
+	if err != nil {
+		return domain.Product{}, err
+	}
+	if product.ParentID != 0 {
+		if _, err := s.repo.GetByID(product.ParentID); err != nil {
+			return domain.Product{}, ErrRestoreDependency
+		}
+	}
+	for _, c := range product.Components {
+		if _, err := s.repo.GetByID(c.ProductID); err != nil {
+			return domain.Product{}, ErrRestoreDependency
+		}
+	}
+	if _, err := s.repo.Restore(id); err != nil {
+		return domain.Product{}, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Purge deletes a product in the trash for good, freeing its code value
+func (s *service) Purge(ctx context.Context, id int) error {
+	if _, err := s.repo.GetTrashed(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// PurgeDue deletes the products kept in the trash past their retention and
+// returns how many were purged
+func (s *service) PurgeDue(ctx context.Context) int {
+	now := time.Now().UTC()
+	purged := 0
+	for _, product := range s.repo.GetTrash() {
+		if product.PurgeAt == nil || product.PurgeAt.After(now) {
+			continue
+		}
+		if err := s.repo.Delete(product.ID); err == nil {
+			purged++
+		}
+	}
+	return purged
 }
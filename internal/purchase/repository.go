@@ -0,0 +1,76 @@
+package purchase
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound              = errors.New("purchase order not found")
+	ErrCreatingPurchaseOrder = errors.New("error creating purchase order")
+	ErrUpdatingPurchaseOrder = errors.New("error updating purchase order")
+	ErrNotOpen               = errors.New("purchase order is not open")
+)
+
+type Repository interface {
+	GetAll() []domain.PurchaseOrder
+	GetByID(id int) (domain.PurchaseOrder, error)
+	Create(o domain.PurchaseOrder) (int, error)
+	Update(o domain.PurchaseOrder) (domain.PurchaseOrder, error)
+	SupplierInUse(supplierID int) bool
+}
+
+type repository struct {
+	storage store.PurchaseOrderStore
+}
+
+func NewRepository(storage store.PurchaseOrderStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all purchase orders
+func (r *repository) GetAll() []domain.PurchaseOrder {
+	orders, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.PurchaseOrder{}
+	}
+	return orders
+}
+
+// search purchase order by ID
+func (r *repository) GetByID(id int) (domain.PurchaseOrder, error) {
+	order, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.PurchaseOrder{}, ErrNotFound
+	}
+	return order, nil
+}
+
+// adds a new purchase order
+func (r *repository) Create(order domain.PurchaseOrder) (int, error) {
+	id, err := r.storage.AddOne(order)
+	if err != nil {
+		return 0, ErrCreatingPurchaseOrder
+	}
+	return id, nil
+}
+
+// updates a purchase order
+func (r *repository) Update(order domain.PurchaseOrder) (domain.PurchaseOrder, error) {
+	if err := r.storage.UpdateOne(order); err != nil {
+		return domain.PurchaseOrder{}, ErrUpdatingPurchaseOrder
+	}
+	return order, nil
+}
+
+// validates if any purchase order was placed with a supplier
+func (r *repository) SupplierInUse(supplierID int) bool {
+	for _, order := range r.GetAll() {
+		if order.SupplierID == supplierID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package purchase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/supplier"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.PurchaseOrder, error)
+	GetAll(ctx context.Context) []domain.PurchaseOrder
+	Create(ctx context.Context, purchaseOrderRequest domain.PurchaseOrderRequest) (domain.PurchaseOrder, error)
+	Receive(ctx context.Context, id int) (domain.PurchaseOrder, error)
+	Cancel(ctx context.Context, id int) (domain.PurchaseOrder, error)
+}
+
+type service struct {
+	repo       Repository
+	suppliers  supplier.Service
+	products   product.Service
+	warehouses warehouse.Service
+}
+
+func NewService(repo Repository, suppliers supplier.Service, products product.Service, warehouses warehouse.Service) Service {
+	return &service{repo, suppliers, products, warehouses}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.PurchaseOrder {
+	return s.repo.GetAll()
+}
+
+// Create places an order with a supplier, costing each line at the supplier
+// cost of the product. Lines of the same product are merged.
+func (s *service) Create(ctx context.Context, purchaseOrderRequest domain.PurchaseOrderRequest) (domain.PurchaseOrder, error) {
+	seller, err := s.suppliers.Get(ctx, purchaseOrderRequest.SupplierID)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	if purchaseOrderRequest.WarehouseID != 0 {
+		if _, err := s.warehouses.Get(ctx, purchaseOrderRequest.WarehouseID); err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+	}
+	order := domain.PurchaseOrder{
+		Status:      domain.PurchaseOrderStatusOpen,
+		SupplierID:  seller.ID,
+		WarehouseID: purchaseOrderRequest.WarehouseID,
+		Currency:    seller.Currency,
+		Lines:       []domain.PurchaseOrderLine{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	var index = map[int]int{}
+	var total float64
+	for _, line := range purchaseOrderRequest.Lines {
+		if line.Quantity <= 0 {
+			return domain.PurchaseOrder{}, product.ErrQuantityOutOfRange
+		}
+		offer, err := s.suppliers.Offer(ctx, seller.ID, line.ProductID)
+		if err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		total += offer.Cost * float64(line.Quantity)
+		if i, ok := index[line.ProductID]; ok {
+			order.Lines[i].Quantity += line.Quantity
+			continue
+		}
+		index[line.ProductID] = len(order.Lines)
+		order.Lines = append(order.Lines, domain.PurchaseOrderLine{
+			ProductID:    line.ProductID,
+			SupplierCode: offer.SupplierCode,
+			Quantity:     line.Quantity,
+			Unit:         offer.Unit,
+			UnitCost:     offer.Cost,
+		})
+	}
+	order.Total = roundFloat(total, 2)
+	order.ID, err = s.repo.Create(order)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	return order, nil
+}
+
+// Receive adds the ordered units to stock as receipt movements and updates
+// the average cost of every product received. The order is reopened if its
+// units can not be received.
+func (s *service) Receive(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	if order.Status != domain.PurchaseOrderStatusOpen {
+		return domain.PurchaseOrder{}, ErrNotOpen
+	}
+	//Packs are received as the base units they hold
+	var deltas = map[int]int{}
+	var costs = map[int]float64{}
+	for _, line := range order.Lines {
+		received, err := s.products.Get(ctx, line.ProductID)
+		if err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		pack, err := received.Pack(line.Unit)
+		if err != nil {
+			return domain.PurchaseOrder{}, err
+		}
+		deltas[line.ProductID] = line.Quantity * pack.Factor
+		costs[line.ProductID] = line.UnitCost / float64(pack.Factor)
+	}
+	//The order is marked received first, so a receipt is never taken twice
+	previous := order
+	now := time.Now().UTC()
+	order.Status = domain.PurchaseOrderStatusReceived
+	order.ReceivedAt = &now
+	received, err := s.repo.Update(order)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	err = s.products.ReceiveAtCost(ctx, deltas, costs, order.Currency, domain.Movement{
+		WarehouseID: order.WarehouseID,
+		Type:        domain.MovementReceipt,
+		Reference:   fmt.Sprintf("purchase order %d", order.ID),
+	})
+	if err != nil {
+		if _, reopenErr := s.repo.Update(previous); reopenErr != nil {
+			return domain.PurchaseOrder{}, fmt.Errorf("%w, and the order could not be reopened: %v", err, reopenErr)
+		}
+		return domain.PurchaseOrder{}, err
+	}
+	return received, nil
+}
+
+// Cancel closes an open purchase order without receiving it. It holds the
+// stock lock, as Receive does, so an order cannot be both.
+func (s *service) Cancel(ctx context.Context, id int) (domain.PurchaseOrder, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	if order.Status != domain.PurchaseOrderStatusOpen {
+		return domain.PurchaseOrder{}, ErrNotOpen
+	}
+	order.Status = domain.PurchaseOrderStatusCancelled
+	return s.repo.Update(order)
+}
+
+func roundFloat(val float64, precision uint) float64 {
+	ratio := math.Pow(10, float64(precision))
+	return math.Round(val*ratio) / ratio
+}
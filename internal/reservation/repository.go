@@ -0,0 +1,84 @@
+package reservation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound            = errors.New("reservation not found")
+	ErrCreatingReservation = errors.New("error creating reservation")
+	ErrUpdatingReservation = errors.New("error updating reservation")
+	ErrNotActive           = errors.New("reservation is not active")
+	ErrTTLOutOfRange       = errors.New("ttl_seconds must be between 1 and 86400")
+)
+
+type Repository interface {
+	GetAll() []domain.Reservation
+	GetByID(id int) (domain.Reservation, error)
+	Create(r domain.Reservation) (int, error)
+	Update(r domain.Reservation) (domain.Reservation, error)
+	Held(excludeID int) map[int]int
+}
+
+type repository struct {
+	storage store.ReservationStore
+}
+
+func NewRepository(storage store.ReservationStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all reservations
+func (r *repository) GetAll() []domain.Reservation {
+	reservations, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Reservation{}
+	}
+	return reservations
+}
+
+// search reservation by ID
+func (r *repository) GetByID(id int) (domain.Reservation, error) {
+	reservation, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Reservation{}, ErrNotFound
+	}
+	return reservation, nil
+}
+
+// adds a new reservation
+func (r *repository) Create(reservation domain.Reservation) (int, error) {
+	id, err := r.storage.AddOne(reservation)
+	if err != nil {
+		return 0, ErrCreatingReservation
+	}
+	return id, nil
+}
+
+// updates a reservation
+func (r *repository) Update(reservation domain.Reservation) (domain.Reservation, error) {
+	if err := r.storage.UpdateOne(reservation); err != nil {
+		return domain.Reservation{}, ErrUpdatingReservation
+	}
+	return reservation, nil
+}
+
+// sums the units of each product held by unexpired active reservations,
+// leaving out the reservation with excludeID
+func (r *repository) Held(excludeID int) map[int]int {
+	held := map[int]int{}
+	now := time.Now()
+	for _, reservation := range r.GetAll() {
+		if reservation.ID == excludeID || !reservation.IsHolding(now) {
+			continue
+		}
+		for _, line := range reservation.Lines {
+			held[line.ProductID] += line.Quantity
+		}
+	}
+	return held
+}
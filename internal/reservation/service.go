@@ -0,0 +1,114 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+)
+
+const (
+	DefaultTTL = 15 * time.Minute
+	MaxTTL     = 24 * time.Hour
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Reservation, error)
+	GetAll(ctx context.Context) []domain.Reservation
+	Create(ctx context.Context, reservationRequest domain.ReservationRequest) (domain.Reservation, error)
+	Cancel(ctx context.Context, id int) (domain.Reservation, error)
+	Consume(ctx context.Context, id int) (domain.Reservation, error)
+	ExpireDue(ctx context.Context) int
+}
+
+type service struct {
+	repo     Repository
+	products product.Service
+}
+
+func NewService(repo Repository, products product.Service) Service {
+	return &service{repo, products}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Reservation, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Reservation {
+	return s.repo.GetAll()
+}
+
+// Create holds the requested units for the TTL if they are published and
+// not already held or sold
+func (s *service) Create(ctx context.Context, reservationRequest domain.ReservationRequest) (domain.Reservation, error) {
+	ttl := DefaultTTL
+	if reservationRequest.TTLSeconds != 0 {
+		ttl = time.Duration(reservationRequest.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > MaxTTL {
+		return domain.Reservation{}, ErrTTLOutOfRange
+	}
+
+	unlock := s.products.LockStock()
+	defer unlock()
+	quote, err := s.products.Quote(ctx, reservationRequest.Lines, domain.PriceOptions{})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	now := time.Now().UTC()
+	reservation := domain.Reservation{
+		Status:    domain.ReservationStatusActive,
+		Lines:     []domain.LineRequest{},
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	for _, line := range quote.Lines {
+		reservation.Lines = append(reservation.Lines, domain.LineRequest{ProductID: line.ProductID, Quantity: line.Quantity})
+	}
+	reservation.ID, err = s.repo.Create(reservation)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	return reservation, nil
+}
+
+// Cancel releases the units of an active reservation
+func (s *service) Cancel(ctx context.Context, id int) (domain.Reservation, error) {
+	return s.close(id, domain.ReservationStatusCancelled)
+}
+
+// Consume marks an active reservation as turned into an order
+func (s *service) Consume(ctx context.Context, id int) (domain.Reservation, error) {
+	return s.close(id, domain.ReservationStatusConsumed)
+}
+
+// ExpireDue marks active reservations past their expiration as expired and
+// returns how many were updated. Expired units are released as soon as the
+// expiration passes; this only keeps the stored status accurate.
+func (s *service) ExpireDue(ctx context.Context) int {
+	now := time.Now()
+	expired := 0
+	for _, reservation := range s.repo.GetAll() {
+		if reservation.Status != domain.ReservationStatusActive || reservation.IsHolding(now) {
+			continue
+		}
+		reservation.Status = domain.ReservationStatusExpired
+		if _, err := s.repo.Update(reservation); err == nil {
+			expired++
+		}
+	}
+	return expired
+}
+
+func (s *service) close(id int, status string) (domain.Reservation, error) {
+	reservation, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	if !reservation.IsHolding(time.Now()) {
+		return domain.Reservation{}, ErrNotActive
+	}
+	reservation.Status = status
+	return s.repo.Update(reservation)
+}
@@ -0,0 +1,65 @@
+package stocktake
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound          = errors.New("stocktake not found")
+	ErrCreatingStocktake = errors.New("error creating stocktake")
+	ErrUpdatingStocktake = errors.New("error updating stocktake")
+	ErrNotOpen           = errors.New("stocktake is not open")
+)
+
+type Repository interface {
+	GetAll() []domain.Stocktake
+	GetByID(id int) (domain.Stocktake, error)
+	Create(s domain.Stocktake) (int, error)
+	Update(s domain.Stocktake) (domain.Stocktake, error)
+}
+
+type repository struct {
+	storage store.StocktakeStore
+}
+
+func NewRepository(storage store.StocktakeStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all stocktakes
+func (r *repository) GetAll() []domain.Stocktake {
+	stocktakes, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Stocktake{}
+	}
+	return stocktakes
+}
+
+// search stocktake by ID
+func (r *repository) GetByID(id int) (domain.Stocktake, error) {
+	stocktake, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Stocktake{}, ErrNotFound
+	}
+	return stocktake, nil
+}
+
+// adds a new stocktake
+func (r *repository) Create(stocktake domain.Stocktake) (int, error) {
+	id, err := r.storage.AddOne(stocktake)
+	if err != nil {
+		return 0, ErrCreatingStocktake
+	}
+	return id, nil
+}
+
+// updates a stocktake
+func (r *repository) Update(stocktake domain.Stocktake) (domain.Stocktake, error) {
+	if err := r.storage.UpdateOne(stocktake); err != nil {
+		return domain.Stocktake{}, ErrUpdatingStocktake
+	}
+	return stocktake, nil
+}
@@ -0,0 +1,196 @@
+package stocktake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+	"github.com/hernan-hdiaz/go-web/internal/warehouse"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Stocktake, error)
+	GetAll(ctx context.Context) []domain.Stocktake
+	Open(ctx context.Context, stocktakeRequest domain.StocktakeRequest) (domain.Stocktake, error)
+	Count(ctx context.Context, id int, countRequest domain.CountRequest) (domain.Stocktake, error)
+	Variances(ctx context.Context, id int) ([]domain.StockVariance, error)
+	Commit(ctx context.Context, id int) (domain.Stocktake, error)
+	Cancel(ctx context.Context, id int) (domain.Stocktake, error)
+}
+
+type service struct {
+	repo       Repository
+	products   product.Service
+	warehouses warehouse.Service
+}
+
+func NewService(repo Repository, products product.Service, warehouses warehouse.Service) Service {
+	return &service{repo, products, warehouses}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Stocktake, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Stocktake {
+	return s.repo.GetAll()
+}
+
+// Open starts a count session for a warehouse, or for the product totals
+// without one
+func (s *service) Open(ctx context.Context, stocktakeRequest domain.StocktakeRequest) (domain.Stocktake, error) {
+	if stocktakeRequest.WarehouseID != 0 {
+		if _, err := s.warehouses.Get(ctx, stocktakeRequest.WarehouseID); err != nil {
+			return domain.Stocktake{}, err
+		}
+	}
+	stocktake := domain.Stocktake{
+		Status:      domain.StocktakeStatusOpen,
+		WarehouseID: stocktakeRequest.WarehouseID,
+		Counts:      []domain.StockCount{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	var err error
+	stocktake.ID, err = s.repo.Create(stocktake)
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	return stocktake, nil
+}
+
+// Count adds a batch of counted quantities to an open session, each with the
+// system quantity at the time it was counted. Counting a product again
+// replaces its previous count. It holds the stock lock, as Commit does, so
+// a session is never counted back open after it was committed.
+func (s *service) Count(ctx context.Context, id int, countRequest domain.CountRequest) (domain.Stocktake, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	stocktake, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	if stocktake.Status != domain.StocktakeStatusOpen {
+		return domain.Stocktake{}, ErrNotOpen
+	}
+	for i, count := range countRequest.Counts {
+		p, err := s.products.Get(ctx, count.ProductID)
+		if err != nil {
+			return domain.Stocktake{}, err
+		}
+		if p.IsBundle() {
+			return domain.Stocktake{}, product.ErrBundleStock
+		}
+		countRequest.Counts[i].Expected = p.Quantity
+		if stocktake.WarehouseID != 0 {
+			countRequest.Counts[i].Expected = p.WarehouseQuantity(stocktake.WarehouseID)
+		}
+	}
+	for _, count := range countRequest.Counts {
+		stocktake.Counts = setCount(stocktake.Counts, count)
+	}
+	return s.repo.Update(stocktake)
+}
+
+// Variances compares the counts of a session against the system quantities
+// they were counted at
+func (s *service) Variances(ctx context.Context, id int) ([]domain.StockVariance, error) {
+	stocktake, err := s.repo.GetByID(id)
+	if err != nil {
+		return []domain.StockVariance{}, err
+	}
+	if stocktake.Status == domain.StocktakeStatusCommitted {
+		return stocktake.Variances, nil
+	}
+	return s.variances(ctx, stocktake)
+}
+
+// Commit applies the variances of an open session as adjustment movements,
+// all or nothing, and closes it. Variances are taken against the quantities
+// at count time, so stock moved since then is not overwritten.
+func (s *service) Commit(ctx context.Context, id int) (domain.Stocktake, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	stocktake, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	if stocktake.Status != domain.StocktakeStatusOpen {
+		return domain.Stocktake{}, ErrNotOpen
+	}
+	variances, err := s.variances(ctx, stocktake)
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	deltas := map[int]int{}
+	for _, variance := range variances {
+		if variance.Variance != 0 {
+			deltas[variance.ProductID] = variance.Variance
+		}
+	}
+	if len(deltas) > 0 {
+		err = s.products.AdjustStock(ctx, deltas, domain.Movement{
+			WarehouseID: stocktake.WarehouseID,
+			Type:        domain.MovementAdjustment,
+			Reason:      "stocktake count",
+			Reference:   fmt.Sprintf("stocktake %d", stocktake.ID),
+		})
+		if err != nil {
+			return domain.Stocktake{}, err
+		}
+	}
+	now := time.Now().UTC()
+	stocktake.Status = domain.StocktakeStatusCommitted
+	stocktake.Variances = variances
+	stocktake.CommittedAt = &now
+	return s.repo.Update(stocktake)
+}
+
+// Cancel closes an open session without touching stock. It holds the stock
+// lock, as Commit does, so a session is never both.
+func (s *service) Cancel(ctx context.Context, id int) (domain.Stocktake, error) {
+	unlock := s.products.LockStock()
+	defer unlock()
+	stocktake, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	if stocktake.Status != domain.StocktakeStatusOpen {
+		return domain.Stocktake{}, ErrNotOpen
+	}
+	stocktake.Status = domain.StocktakeStatusCancelled
+	return s.repo.Update(stocktake)
+}
+
+func (s *service) variances(ctx context.Context, stocktake domain.Stocktake) ([]domain.StockVariance, error) {
+	var variances = []domain.StockVariance{}
+	for _, count := range stocktake.Counts {
+		p, err := s.products.Get(ctx, count.ProductID)
+		if err != nil {
+			return []domain.StockVariance{}, err
+		}
+		variances = append(variances, domain.StockVariance{
+			ProductID: p.ID,
+			Name:      p.Name,
+			Expected:  count.Expected,
+			Counted:   count.Counted,
+			Variance:  count.Counted - count.Expected,
+		})
+	}
+	return variances, nil
+}
+
+// replaces the count of a product or adds it, keeping counts sorted by product
+func setCount(counts []domain.StockCount, count domain.StockCount) []domain.StockCount {
+	for i, c := range counts {
+		if c.ProductID == count.ProductID {
+			counts[i] = count
+			return counts
+		}
+	}
+	counts = append(counts, count)
+	sort.Slice(counts, func(i, j int) bool { return counts[i].ProductID < counts[j].ProductID })
+	return counts
+}
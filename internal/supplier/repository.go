@@ -0,0 +1,92 @@
+package supplier
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound         = errors.New("supplier not found")
+	ErrCreatingSupplier = errors.New("error creating supplier")
+	ErrUpdatingSupplier = errors.New("error updating supplier")
+	ErrCostOutOfRange   = errors.New("cost must be greater than 0")
+	ErrInUse            = errors.New("supplier has purchase orders")
+	ErrNotSupplied      = errors.New("product is not sold by the supplier")
+)
+
+type Repository interface {
+	GetAll() []domain.Supplier
+	GetByID(id int) (domain.Supplier, error)
+	Create(s domain.Supplier) (int, error)
+	Update(s domain.Supplier) (domain.Supplier, error)
+	Delete(id int) error
+	CurrencyInUse(currency string) bool
+}
+
+type repository struct {
+	storage store.SupplierStore
+}
+
+func NewRepository(storage store.SupplierStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all suppliers
+func (r *repository) GetAll() []domain.Supplier {
+	suppliers, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Supplier{}
+	}
+	return suppliers
+}
+
+// search supplier by ID
+func (r *repository) GetByID(id int) (domain.Supplier, error) {
+	supplier, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Supplier{}, ErrNotFound
+	}
+	return supplier, nil
+}
+
+// adds a new supplier
+func (r *repository) Create(s domain.Supplier) (int, error) {
+	id, err := r.storage.AddOne(s)
+	if err != nil {
+		return 0, ErrCreatingSupplier
+	}
+	return id, nil
+}
+
+// updates a supplier
+func (r *repository) Update(s domain.Supplier) (domain.Supplier, error) {
+	if err := r.storage.UpdateOne(s); err != nil {
+		return domain.Supplier{}, ErrUpdatingSupplier
+	}
+	return s, nil
+}
+
+// deletes a supplier
+func (r *repository) Delete(id int) error {
+	if err := r.storage.DeleteOne(id); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// validates if any supplier sells in a currency
+func (r *repository) CurrencyInUse(currency string) bool {
+	suppliers, err := r.storage.GetAll()
+	if err != nil {
+		return true
+	}
+	for _, supplier := range suppliers {
+		if strings.EqualFold(supplier.Currency, currency) {
+			return true
+		}
+	}
+	return false
+}
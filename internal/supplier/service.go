@@ -0,0 +1,132 @@
+package supplier
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hernan-hdiaz/go-web/internal/currency"
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/internal/product"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Supplier, error)
+	GetAll(ctx context.Context) []domain.Supplier
+	Save(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error)
+	Update(ctx context.Context, supplier domain.Supplier, id int) (domain.Supplier, error)
+	Delete(ctx context.Context, id int) error
+	Offer(ctx context.Context, id int, productID int) (domain.SupplierProduct, error)
+}
+
+// PurchaseChecker reports whether a supplier has purchase orders
+type PurchaseChecker interface {
+	SupplierInUse(supplierID int) bool
+}
+
+type service struct {
+	repo      Repository
+	products  product.Service
+	rates     currency.Service
+	purchases PurchaseChecker
+}
+
+func NewService(repo Repository, products product.Service, rates currency.Service, purchases PurchaseChecker) Service {
+	return &service{repo, products, rates, purchases}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Supplier {
+	return s.repo.GetAll()
+}
+
+func (s *service) Save(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error) {
+	unlock := s.rates.LockRates()
+	defer unlock()
+	supplier, err := s.validate(ctx, supplier)
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	supplier.ID, err = s.repo.Create(supplier)
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (s *service) Update(ctx context.Context, supplier domain.Supplier, id int) (domain.Supplier, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return domain.Supplier{}, err
+	}
+	unlock := s.rates.LockRates()
+	defer unlock()
+	supplier, err := s.validate(ctx, supplier)
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	supplier.ID = id
+	return s.repo.Update(supplier)
+}
+
+// Delete removes a supplier no purchase order was placed with
+func (s *service) Delete(ctx context.Context, id int) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return err
+	}
+	if s.purchases.SupplierInUse(id) {
+		return ErrInUse
+	}
+	return s.repo.Delete(id)
+}
+
+// Offer returns the code and cost a supplier sells a product at
+func (s *service) Offer(ctx context.Context, id int, productID int) (domain.SupplierProduct, error) {
+	supplier, err := s.repo.GetByID(id)
+	if err != nil {
+		return domain.SupplierProduct{}, err
+	}
+	for _, p := range supplier.Products {
+		if p.ProductID == productID {
+			return p, nil
+		}
+	}
+	return domain.SupplierProduct{}, ErrNotSupplied
+}
+
+// checks the currency, costs and products of a supplier, keeping the last
+// entry of a repeated product
+func (s *service) validate(ctx context.Context, supplier domain.Supplier) (domain.Supplier, error) {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	code, err := s.rates.Validate(ctx, supplier.Currency)
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	supplier.Currency = code
+	var products = []domain.SupplierProduct{}
+	var index = map[int]int{}
+	for _, p := range supplier.Products {
+		if p.Cost <= 0 {
+			return domain.Supplier{}, ErrCostOutOfRange
+		}
+		found, err := s.products.Get(ctx, p.ProductID)
+		if err != nil {
+			return domain.Supplier{}, err
+		}
+		if found.IsBundle() {
+			return domain.Supplier{}, product.ErrBundleStock
+		}
+		if _, err := found.Pack(p.Unit); err != nil {
+			return domain.Supplier{}, err
+		}
+		if i, ok := index[p.ProductID]; ok {
+			products[i] = p
+			continue
+		}
+		index[p.ProductID] = len(products)
+		products = append(products, p)
+	}
+	supplier.Products = products
+	return supplier, nil
+}
@@ -0,0 +1,93 @@
+package warehouse
+
+import (
+	"errors"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+	"github.com/hernan-hdiaz/go-web/pkg/store"
+)
+
+var (
+	ErrNotFound          = errors.New("warehouse not found")
+	ErrCreatingWarehouse = errors.New("error creating warehouse")
+	ErrUpdatingWarehouse = errors.New("error updating warehouse")
+	ErrAlreadyExists     = errors.New("warehouse code already exists")
+	ErrInUse             = errors.New("warehouse still has stock")
+	ErrDefaultWarehouse  = errors.New("default warehouse can not be deleted")
+)
+
+type Repository interface {
+	GetAll() []domain.Warehouse
+	GetByID(id int) (domain.Warehouse, error)
+	Create(w domain.Warehouse) (int, error)
+	Update(w domain.Warehouse) (domain.Warehouse, error)
+	Delete(id int) error
+	ValidateCode(code string, id int) bool
+}
+
+type repository struct {
+	storage store.WarehouseStore
+}
+
+func NewRepository(storage store.WarehouseStore) Repository {
+	return &repository{storage}
+}
+
+// retrieves all warehouses
+func (r *repository) GetAll() []domain.Warehouse {
+	warehouses, err := r.storage.GetAll()
+	if err != nil {
+		return []domain.Warehouse{}
+	}
+	return warehouses
+}
+
+// search warehouse by ID
+func (r *repository) GetByID(id int) (domain.Warehouse, error) {
+	warehouse, err := r.storage.GetOne(id)
+	if err != nil {
+		return domain.Warehouse{}, ErrNotFound
+	}
+	return warehouse, nil
+}
+
+// adds a new warehouse
+func (r *repository) Create(w domain.Warehouse) (int, error) {
+	if !r.ValidateCode(w.Code, 0) {
+		return 0, ErrAlreadyExists
+	}
+	id, err := r.storage.AddOne(w)
+	if err != nil {
+		return 0, ErrCreatingWarehouse
+	}
+	return id, nil
+}
+
+// updates a warehouse
+func (r *repository) Update(w domain.Warehouse) (domain.Warehouse, error) {
+	if !r.ValidateCode(w.Code, w.ID) {
+		return domain.Warehouse{}, ErrAlreadyExists
+	}
+	if err := r.storage.UpdateOne(w); err != nil {
+		return domain.Warehouse{}, ErrUpdatingWarehouse
+	}
+	return w, nil
+}
+
+// deletes a warehouse
+func (r *repository) Delete(id int) error {
+	if err := r.storage.DeleteOne(id); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// validates if the code is free, ignoring the warehouse with id
+func (r *repository) ValidateCode(code string, id int) bool {
+	for _, warehouse := range r.GetAll() {
+		if warehouse.Code == code && warehouse.ID != id {
+			return false
+		}
+	}
+	return true
+}
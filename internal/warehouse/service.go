@@ -0,0 +1,71 @@
+package warehouse
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type Service interface {
+	Get(ctx context.Context, id int) (domain.Warehouse, error)
+	GetAll(ctx context.Context) []domain.Warehouse
+	Save(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error)
+	Update(ctx context.Context, warehouse domain.Warehouse, id int) (domain.Warehouse, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// StockChecker reports whether a warehouse still holds units of any product
+type StockChecker interface {
+	WarehouseInUse(warehouseID int) bool
+}
+
+type service struct {
+	repo  Repository
+	stock StockChecker
+}
+
+func NewService(repo Repository, stock StockChecker) Service {
+	return &service{repo, stock}
+}
+
+func (s *service) Get(ctx context.Context, id int) (domain.Warehouse, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *service) GetAll(ctx context.Context) []domain.Warehouse {
+	return s.repo.GetAll()
+}
+
+func (s *service) Save(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error) {
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(warehouse.Code))
+	var err error
+	warehouse.ID, err = s.repo.Create(warehouse)
+	if err != nil {
+		return domain.Warehouse{}, err
+	}
+	return warehouse, nil
+}
+
+func (s *service) Update(ctx context.Context, warehouse domain.Warehouse, id int) (domain.Warehouse, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return domain.Warehouse{}, err
+	}
+	warehouse.ID = id
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(warehouse.Code))
+	return s.repo.Update(warehouse)
+}
+
+// Delete removes an empty warehouse other than the default one
+func (s *service) Delete(ctx context.Context, id int) error {
+	if id == domain.DefaultWarehouseID {
+		return ErrDefaultWarehouse
+	}
+	if _, err := s.repo.GetByID(id); err != nil {
+		return err
+	}
+	if s.stock.WarehouseInUse(id) {
+		return ErrInUse
+	}
+	return s.repo.Delete(id)
+}
@@ -0,0 +1,101 @@
+// Package gtin validates and normalizes codes of the GTIN family: EAN-13,
+// UPC-A and GTIN-14, which differ only in their number of leading zeros.
+package gtin
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	FormatEAN13  = "ean13"
+	FormatUPCA   = "upca"
+	FormatGTIN14 = "gtin14"
+)
+
+var (
+	ErrUnknownFormat = errors.New("code_format must be one of ean13, upca or gtin14")
+	ErrInvalidCode   = errors.New("code_value is not a GTIN of the required length")
+	ErrCheckDigit    = errors.New("code_value check digit does not match")
+)
+
+var lengths = map[string]int{
+	FormatEAN13:  13,
+	FormatUPCA:   12,
+	FormatGTIN14: 14,
+}
+
+// the lengths a GTIN is written in: GTIN-8, UPC-A, EAN-13 and GTIN-14
+var gtinLengths = map[int]bool{8: true, 12: true, 13: true, 14: true}
+
+// ValidFormat reports whether format is a known code format
+func ValidFormat(format string) bool {
+	_, ok := lengths[format]
+	return ok
+}
+
+// Normalize trims and uppercases a code
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Format normalizes code to the length of format, adding or dropping leading
+// zeros, and validates its check digit. Only codes written in one of the GTIN
+// lengths are accepted, so a short number is not taken for a padded one.
+func Format(code string, format string) (string, error) {
+	length, ok := lengths[format]
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+	code = Normalize(code)
+	if !numeric(code) || !gtinLengths[len(code)] {
+		return "", ErrInvalidCode
+	}
+	for len(code) > length && code[0] == '0' {
+		code = code[1:]
+	}
+	if len(code) > length {
+		return "", ErrInvalidCode
+	}
+	code = strings.Repeat("0", length-len(code)) + code
+	if !validCheckDigit(code) {
+		return "", ErrCheckDigit
+	}
+	return code, nil
+}
+
+// Canonical returns the GTIN-14 form of any valid GTIN code, whatever its
+// number of leading zeros
+func Canonical(code string) (string, bool) {
+	canonical, err := Format(code, FormatGTIN14)
+	if err != nil || len(strings.TrimLeft(canonical, "0")) < 8 {
+		return "", false
+	}
+	return canonical, true
+}
+
+func numeric(code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validates the last digit against the others, weighted 3 and 1 alternately
+// from the right
+func validCheckDigit(code string) bool {
+	var sum int
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return int(code[len(code)-1]-'0') == (10-sum%10)%10
+}
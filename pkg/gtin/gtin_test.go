@@ -0,0 +1,81 @@
+package gtin_test
+
+import (
+	"testing"
+
+	"github.com/hernan-hdiaz/go-web/pkg/gtin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		format   string
+		expected string
+		err      error
+	}{
+		{"GTIN-8 padded to EAN-13", "96385074", gtin.FormatEAN13, "0000096385074", nil},
+		{"UPC-A padded to EAN-13", "036000291452", gtin.FormatEAN13, "0036000291452", nil},
+		{"EAN-13 kept", "4006381333931", gtin.FormatEAN13, "4006381333931", nil},
+		{"EAN-13 trimmed", " 4006381333931 ", gtin.FormatEAN13, "4006381333931", nil},
+		{"EAN-13 cut to UPC-A", "0036000291452", gtin.FormatUPCA, "036000291452", nil},
+		{"GTIN-14 cut to UPC-A", "00036000291452", gtin.FormatUPCA, "036000291452", nil},
+		{"EAN-13 padded to GTIN-14", "4006381333931", gtin.FormatGTIN14, "04006381333931", nil},
+		{"GTIN-14 with an indicator kept", "10036000291459", gtin.FormatGTIN14, "10036000291459", nil},
+		{"GTIN-14 with an indicator too long for EAN-13", "10036000291459", gtin.FormatEAN13, "", gtin.ErrInvalidCode},
+		{"bad check digit", "4006381333932", gtin.FormatEAN13, "", gtin.ErrCheckDigit},
+		{"bad GTIN-8 check digit", "96385075", gtin.FormatEAN13, "", gtin.ErrCheckDigit},
+		{"short number", "17", gtin.FormatEAN13, "", gtin.ErrInvalidCode},
+		{"UPC-A missing its leading zero", "36000291452", gtin.FormatEAN13, "", gtin.ErrInvalidCode},
+		{"too long", "040063813339310", gtin.FormatGTIN14, "", gtin.ErrInvalidCode},
+		{"not digits", "40063813339A1", gtin.FormatEAN13, "", gtin.ErrInvalidCode},
+		{"empty", "", gtin.FormatEAN13, "", gtin.ErrInvalidCode},
+		{"unknown format", "4006381333931", "isbn", "", gtin.ErrUnknownFormat},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := gtin.Format(test.code, test.format)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, code)
+		})
+	}
+}
+
+func Test_Canonical(t *testing.T) {
+	//Padded and unpadded forms of a code are the same code
+	for _, code := range []string{"036000291452", "0036000291452", "00036000291452"} {
+		canonical, ok := gtin.Canonical(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, "00036000291452", canonical, code)
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+		ok       bool
+	}{
+		{"GTIN-8", "96385074", "00000096385074", true},
+		{"EAN-13", "4006381333931", "04006381333931", true},
+		{"GTIN-14", "10036000291459", "10036000291459", true},
+		{"bad check digit", "4006381333932", "", false},
+		{"not a GTIN", "TEST45050", "", false},
+		{"short number", "17", "", false},
+		{"only zeros", "00000000", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canonical, ok := gtin.Canonical(test.code)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, canonical)
+		})
+	}
+}
+
+func Test_ValidFormat(t *testing.T) {
+	for _, format := range []string{gtin.FormatEAN13, gtin.FormatUPCA, gtin.FormatGTIN14} {
+		assert.True(t, gtin.ValidFormat(format), format)
+	}
+	assert.False(t, gtin.ValidFormat("isbn"))
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+type AttachmentStore interface {
+	GetByProduct(productID int) ([]domain.Attachment, error)
+	GetOne(productID int, id int) (domain.Attachment, error)
+	ReadContent(attachment domain.Attachment) ([]byte, error)
+	AddOne(attachment domain.Attachment, content []byte) (int, error)
+	DeleteOne(productID int, id int) error
+	DeleteByProduct(productID int) error
+	saveAttachments(attachments []domain.Attachment) error
+	loadAttachments() ([]domain.Attachment, error)
+}
+
+type jsonAttachmentStore struct {
+	pathToFile string
+	dir        string
+	mu         sync.RWMutex
+}
+
+// loads attachments from JSON file
+func (s *jsonAttachmentStore) loadAttachments() ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &attachments)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// saves attachments to JSON file
+func (s *jsonAttachmentStore) saveAttachments(attachments []domain.Attachment) error {
+	bytes, err := json.Marshal(attachments)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new attachment store keeping the list of attachments in path
+// and their content under dir, one folder per product
+func NewAttachmentStore(path string, dir string) AttachmentStore {
+	return &jsonAttachmentStore{
+		pathToFile: path,
+		dir:        dir,
+	}
+}
+
+// path of the folder holding the attachments of a product
+func (s *jsonAttachmentStore) productDir(productID int) string {
+	return filepath.Join(s.dir, strconv.Itoa(productID))
+}
+
+// path of the file holding the content of an attachment
+func (s *jsonAttachmentStore) contentPath(attachment domain.Attachment) string {
+	return filepath.Join(s.productDir(attachment.ProductID), strconv.Itoa(attachment.ID))
+}
+
+// retrieves the attachments of a product
+func (s *jsonAttachmentStore) GetByProduct(productID int) ([]domain.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	attachments, err := s.loadAttachments()
+	if err != nil {
+		return nil, err
+	}
+	var found = []domain.Attachment{}
+	for _, attachment := range attachments {
+		if attachment.ProductID == productID {
+			found = append(found, attachment)
+		}
+	}
+	return found, nil
+}
+
+// search attachment of a product by id
+func (s *jsonAttachmentStore) GetOne(productID int, id int) (domain.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	attachments, err := s.loadAttachments()
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	for _, attachment := range attachments {
+		if attachment.ID == id && attachment.ProductID == productID {
+			return attachment, nil
+		}
+	}
+	return domain.Attachment{}, ErrAttachmentNotFound
+}
+
+// reads the content of an attachment
+func (s *jsonAttachmentStore) ReadContent(attachment domain.Attachment) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	content, err := os.ReadFile(s.contentPath(attachment))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrAttachmentNotFound
+	}
+	return content, err
+}
+
+// adds a new attachment, writing its content before listing it
+func (s *jsonAttachmentStore) AddOne(attachment domain.Attachment, content []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attachments, err := s.loadAttachments()
+	if err != nil {
+		return 0, err
+	}
+	attachment.ID = 1
+	for _, a := range attachments {
+		if a.ID >= attachment.ID {
+			attachment.ID = a.ID + 1
+		}
+	}
+	if err := os.MkdirAll(s.productDir(attachment.ProductID), 0755); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(s.contentPath(attachment), content, 0644); err != nil {
+		return 0, err
+	}
+	attachments = append(attachments, attachment)
+	if err := s.saveAttachments(attachments); err != nil {
+		os.Remove(s.contentPath(attachment))
+		return 0, err
+	}
+	return attachment.ID, nil
+}
+
+// deletes an attachment of a product and its content
+func (s *jsonAttachmentStore) DeleteOne(productID int, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attachments, err := s.loadAttachments()
+	if err != nil {
+		return err
+	}
+	for i, a := range attachments {
+		if a.ID == id && a.ProductID == productID {
+			attachments = append(attachments[:i], attachments[i+1:]...)
+			if err := s.saveAttachments(attachments); err != nil {
+				return err
+			}
+			if err := os.Remove(s.contentPath(a)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		}
+	}
+	return ErrAttachmentNotFound
+}
+
+// deletes every attachment of a product and its folder
+func (s *jsonAttachmentStore) DeleteByProduct(productID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attachments, err := s.loadAttachments()
+	if err != nil {
+		return err
+	}
+	var kept = []domain.Attachment{}
+	for _, a := range attachments {
+		if a.ProductID != productID {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) != len(attachments) {
+		if err := s.saveAttachments(kept); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(s.productDir(productID))
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+// AuditStore is append only, entries are never updated nor deleted
+type AuditStore interface {
+	GetAll() ([]domain.AuditEntry, error)
+	AddOne(entry domain.AuditEntry) (int, error)
+	saveEntries(entries []domain.AuditEntry) error
+	loadEntries() ([]domain.AuditEntry, error)
+}
+
+type jsonAuditStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads audit entries from JSON file
+func (s *jsonAuditStore) loadEntries() ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// saves audit entries to JSON file
+func (s *jsonAuditStore) saveEntries(entries []domain.AuditEntry) error {
+	bytes, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new audit store
+func NewAuditStore(path string) AuditStore {
+	return &jsonAuditStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all audit entries, oldest first
+func (s *jsonAuditStore) GetAll() ([]domain.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadEntries()
+}
+
+// appends an audit entry
+func (s *jsonAuditStore) AddOne(entry domain.AuditEntry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.loadEntries()
+	if err != nil {
+		return 0, err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	entries = append(entries, entry)
+	if err = s.saveEntries(entries); err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrCartNotFound = errors.New("cart not found")
+
+type CartStore interface {
+	GetAll() ([]domain.Cart, error)
+	GetOne(id int) (domain.Cart, error)
+	AddOne(cart domain.Cart) (int, error)
+	UpdateOne(cart domain.Cart) error
+	saveCarts(carts []domain.Cart) error
+	loadCarts() ([]domain.Cart, error)
+}
+
+type jsonCartStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads carts from JSON file
+func (s *jsonCartStore) loadCarts() ([]domain.Cart, error) {
+	var carts []domain.Cart
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &carts)
+	if err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+// saves carts to JSON file
+func (s *jsonCartStore) saveCarts(carts []domain.Cart) error {
+	bytes, err := json.Marshal(carts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new cart store
+func NewCartStore(path string) CartStore {
+	return &jsonCartStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all carts
+func (s *jsonCartStore) GetAll() ([]domain.Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	carts, err := s.loadCarts()
+	if err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+// search cart by id
+func (s *jsonCartStore) GetOne(id int) (domain.Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	carts, err := s.loadCarts()
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	for _, cart := range carts {
+		if cart.ID == id {
+			return cart, nil
+		}
+	}
+	return domain.Cart{}, ErrCartNotFound
+}
+
+// adds a new cart
+func (s *jsonCartStore) AddOne(cart domain.Cart) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	carts, err := s.loadCarts()
+	if err != nil {
+		return 0, err
+	}
+	cart.ID = 1
+	for _, c := range carts {
+		if c.ID >= cart.ID {
+			cart.ID = c.ID + 1
+		}
+	}
+	carts = append(carts, cart)
+	if err = s.saveCarts(carts); err != nil {
+		return 0, err
+	}
+	return cart.ID, nil
+}
+
+// updates a cart
+func (s *jsonCartStore) UpdateOne(cart domain.Cart) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	carts, err := s.loadCarts()
+	if err != nil {
+		return err
+	}
+	for i, c := range carts {
+		if c.ID == cart.ID {
+			carts[i] = cart
+			return s.saveCarts(carts)
+		}
+	}
+	return ErrCartNotFound
+}
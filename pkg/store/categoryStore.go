@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrCategoryNotFound = errors.New("category not found")
+
+type CategoryStore interface {
+	GetAll() ([]domain.Category, error)
+	GetOne(id int) (domain.Category, error)
+	AddOne(category domain.Category) (int, error)
+	UpdateOne(category domain.Category) error
+	DeleteOne(id int) error
+	saveCategories(categories []domain.Category) error
+	loadCategories() ([]domain.Category, error)
+}
+
+type jsonCategoryStore struct {
+	pathToFile string
+}
+
+// loads categories from JSON file
+func (s *jsonCategoryStore) loadCategories() ([]domain.Category, error) {
+	var categories []domain.Category
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &categories)
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// saves categories to JSON file
+func (s *jsonCategoryStore) saveCategories(categories []domain.Category) error {
+	bytes, err := json.Marshal(categories)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new category store
+func NewCategoryStore(path string) CategoryStore {
+	return &jsonCategoryStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all categories
+func (s *jsonCategoryStore) GetAll() ([]domain.Category, error) {
+	categories, err := s.loadCategories()
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// search category by id
+func (s *jsonCategoryStore) GetOne(id int) (domain.Category, error) {
+	categories, err := s.loadCategories()
+	if err != nil {
+		return domain.Category{}, err
+	}
+	for _, category := range categories {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+	return domain.Category{}, ErrCategoryNotFound
+}
+
+// adds a new category
+func (s *jsonCategoryStore) AddOne(category domain.Category) (int, error) {
+	categories, err := s.loadCategories()
+	if err != nil {
+		return 0, err
+	}
+	category.ID = 1
+	for _, w := range categories {
+		if w.ID >= category.ID {
+			category.ID = w.ID + 1
+		}
+	}
+	categories = append(categories, category)
+	if err = s.saveCategories(categories); err != nil {
+		return 0, err
+	}
+	return category.ID, nil
+}
+
+// updates a category
+func (s *jsonCategoryStore) UpdateOne(category domain.Category) error {
+	categories, err := s.loadCategories()
+	if err != nil {
+		return err
+	}
+	for i, w := range categories {
+		if w.ID == category.ID {
+			categories[i] = category
+			return s.saveCategories(categories)
+		}
+	}
+	return ErrCategoryNotFound
+}
+
+// deletes a category
+func (s *jsonCategoryStore) DeleteOne(id int) error {
+	categories, err := s.loadCategories()
+	if err != nil {
+		return err
+	}
+	for i, w := range categories {
+		if w.ID == id {
+			categories = append(categories[:i], categories[i+1:]...)
+			return s.saveCategories(categories)
+		}
+	}
+	return ErrCategoryNotFound
+}
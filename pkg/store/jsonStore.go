@@ -3,25 +3,47 @@ package store
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hernan-hdiaz/go-web/internal/domain"
 )
 
-var ErrNotFound = errors.New("product not found")
+var (
+	ErrNotFound          = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVersionConflict   = errors.New("product was changed by someone else")
+)
 
 type Store interface {
 	GetAll() ([]domain.Product, error)
 	GetOne(id int) (domain.Product, error)
 	AddOne(product domain.Product) (int, error)
-	UpdateOne(product domain.Product) error
+	UpdateOne(product domain.Product) (domain.Product, error)
 	DeleteOne(id int) error
+	GetTrash() ([]domain.Product, error)
+	TrashOne(id int, ifVersion *int, actor string, deletedAt time.Time, purgeAt time.Time) error
+	RestoreOne(id int) (domain.Product, error)
+	GetRevisions(id int) ([]domain.Revision, error)
+	LastModified() (time.Time, error)
+	AdjustQuantities(warehouseID int, deltas map[int]int) (map[int]int, error)
+	RestoreQuantities(previous []domain.Product) error
+	TransferQuantity(id int, fromWarehouseID int, toWarehouseID int, quantity int) (domain.Product, error)
+	ReceiveLot(id int, warehouseID int, lot domain.Lot) (domain.Product, error)
 	saveProducts(products []domain.Product) error
 	loadProducts() ([]domain.Product, error)
 }
 
 type jsonStore struct {
-	pathToFile string
+	pathToFile  string
+	attachments AttachmentStore
+	revisions   RevisionStore
+	mu          sync.RWMutex
 }
 
 // loads products from JSON file
@@ -47,76 +69,363 @@ func (s *jsonStore) saveProducts(products []domain.Product) error {
 	return os.WriteFile(s.pathToFile, bytes, 0644)
 }
 
-// creates a new product store
-func NewStore(path string) Store {
+// the file next to the products one that keeps the highest id ever handed
+// out, so the ids of purged products are never given to new ones
+func (s *jsonStore) pathToLastID() string {
+	return strings.TrimSuffix(s.pathToFile, filepath.Ext(s.pathToFile)) + "_last_id.json"
+}
+
+// loads the highest id ever handed out, at least the highest one in products
+func (s *jsonStore) loadLastID(products []domain.Product) (int, error) {
+	lastID := 0
+	file, err := os.ReadFile(s.pathToLastID())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(file, &lastID); err != nil {
+			return 0, err
+		}
+	}
+	for _, p := range products {
+		if p.ID > lastID {
+			lastID = p.ID
+		}
+	}
+	return lastID, nil
+}
+
+// saves the highest id ever handed out
+func (s *jsonStore) saveLastID(lastID int) error {
+	return os.WriteFile(s.pathToLastID(), []byte(strconv.Itoa(lastID)), 0644)
+}
+
+// creates a new product store, keeping a revision of every product it adds
+// or updates and removing the attachments and revisions of the products it
+// deletes
+func NewStore(path string, attachments AttachmentStore, revisions RevisionStore) Store {
 	return &jsonStore{
-		pathToFile: path,
+		pathToFile:  path,
+		attachments: attachments,
+		revisions:   revisions,
 	}
 }
 
-// retrieves all products
+// retrieves all products not in the trash
 func (s *jsonStore) GetAll() ([]domain.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	products, err := s.loadProducts()
 	if err != nil {
 		return nil, err
 	}
-	return products, nil
+	var live = []domain.Product{}
+	for _, product := range products {
+		if !product.InTrash() {
+			live = append(live, product)
+		}
+	}
+	return live, nil
 }
 
-// search product by id
+// search product not in the trash by id
 func (s *jsonStore) GetOne(id int) (domain.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	products, err := s.loadProducts()
 	if err != nil {
 		return domain.Product{}, err
 	}
 	for _, product := range products {
-		if product.ID == id {
+		if product.ID == id && !product.InTrash() {
 			return product, nil
 		}
 	}
 	return domain.Product{}, ErrNotFound
 }
 
-// adds a new product
+// returns when products were last written
+func (s *jsonStore) LastModified() (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info, err := os.Stat(s.pathToFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// retrieves the products in the trash
+func (s *jsonStore) GetTrash() ([]domain.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return nil, err
+	}
+	var trashed = []domain.Product{}
+	for _, product := range products {
+		if product.InTrash() {
+			trashed = append(trashed, product)
+		}
+	}
+	return trashed, nil
+}
+
+// moves a product to the trash, keeping it until purgeAt. When ifVersion is
+// given the product must still be at that version.
+func (s *jsonStore) TrashOne(id int, ifVersion *int, actor string, deletedAt time.Time, purgeAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return err
+	}
+	for i, p := range products {
+		if p.ID == id && !p.InTrash() {
+			if ifVersion != nil && *ifVersion != p.Version {
+				return ErrVersionConflict
+			}
+			products[i].DeletedAt = &deletedAt
+			products[i].DeletedBy = actor
+			products[i].PurgeAt = &purgeAt
+			return s.saveProducts(products)
+		}
+	}
+	return ErrNotFound
+}
+
+// takes a product out of the trash
+func (s *jsonStore) RestoreOne(id int) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return domain.Product{}, err
+	}
+	for i, p := range products {
+		if p.ID == id && p.InTrash() {
+			products[i].DeletedAt = nil
+			products[i].DeletedBy = ""
+			products[i].PurgeAt = nil
+			if err := s.saveProducts(products); err != nil {
+				return domain.Product{}, err
+			}
+			return products[i], nil
+		}
+	}
+	return domain.Product{}, ErrNotFound
+}
+
+// adds a new product with an id no product has ever had
 func (s *jsonStore) AddOne(product domain.Product) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	products, err := s.loadProducts()
 	if err != nil {
 		return 0, err
 	}
-	product.ID = len(products) + 1
+	lastID, err := s.loadLastID(products)
+	if err != nil {
+		return 0, err
+	}
+	product.ID = lastID + 1
+	product.Version = 1
 	products = append(products, product)
+	if err = s.saveLastID(product.ID); err != nil {
+		return 0, err
+	}
 	if err = s.saveProducts(products); err != nil {
 		return 0, err
 	}
+	if _, err = s.revisions.AddOne(product, time.Now().UTC()); err != nil {
+		return 0, err
+	}
 	return product.ID, nil
 }
 
-// updates a product
-func (s *jsonStore) UpdateOne(product domain.Product) error {
+// updates a product, as long as its version is still the one it was read
+// at, and moves it to the next version
+func (s *jsonStore) UpdateOne(product domain.Product) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	products, err := s.loadProducts()
 	if err != nil {
-		return err
+		return domain.Product{}, err
 	}
 	for i, p := range products {
-		if p.ID == product.ID {
+		if p.ID == product.ID && !p.InTrash() {
+			if product.Version != p.Version {
+				return domain.Product{}, ErrVersionConflict
+			}
+			product.Version++
 			products[i] = product
-			return s.saveProducts(products)
+			if err := s.saveProducts(products); err != nil {
+				return domain.Product{}, err
+			}
+			return product, s.addRevision(p, product)
 		}
 	}
-	return ErrNotFound
+	return domain.Product{}, ErrNotFound
+}
+
+// retrieves the revisions of a product, oldest first
+func (s *jsonStore) GetRevisions(id int) ([]domain.Revision, error) {
+	return s.revisions.GetByProduct(id)
+}
+
+// records an update as a revision. Products written before revisions were
+// kept get their previous state as a first revision dated at the zero time.
+func (s *jsonStore) addRevision(previous domain.Product, product domain.Product) error {
+	revisions, err := s.revisions.GetByProduct(product.ID)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		if _, err := s.revisions.AddOne(previous, time.Time{}); err != nil {
+			return err
+		}
+	}
+	_, err = s.revisions.AddOne(product, time.Now().UTC())
+	return err
 }
 
-// deletes a product
+// deletes a product, in the trash or not, with its attachments and revisions
 func (s *jsonStore) DeleteOne(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	products, err := s.loadProducts()
 	if err != nil {
 		return err
 	}
+	lastID, err := s.loadLastID(products)
+	if err != nil {
+		return err
+	}
 	for i, p := range products {
 		if p.ID == id {
+			if err := s.saveLastID(lastID); err != nil {
+				return err
+			}
 			products = append(products[:i], products[i+1:]...)
-			return s.saveProducts(products)
+			if err := s.saveProducts(products); err != nil {
+				return err
+			}
+			if err := s.attachments.DeleteByProduct(id); err != nil {
+				return err
+			}
+			return s.revisions.DeleteByProduct(id)
 		}
 	}
 	return ErrNotFound
 }
+
+// adds each delta to the quantity of its product in a warehouse (any
+// warehouse when 0) and returns the new total quantities. Either every
+// quantity is updated or, when a product is missing or would go below zero,
+// none is.
+func (s *jsonStore) AdjustQuantities(warehouseID int, deltas map[int]int) (map[int]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return nil, err
+	}
+	balances := map[int]int{}
+	for i, p := range products {
+		delta, ok := deltas[p.ID]
+		if !ok || p.InTrash() {
+			continue
+		}
+		if err := products[i].AddStock(warehouseID, delta); err != nil {
+			return nil, fmt.Errorf("%w for product id: %d", ErrInsufficientStock, p.ID)
+		}
+		products[i].Version++
+		balances[p.ID] = products[i].Quantity
+	}
+	if len(balances) != len(deltas) {
+		return nil, ErrNotFound
+	}
+	if err = s.saveProducts(products); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// puts the quantity, warehouse stock, lots and the expiration they set of
+// products back to the ones in copies taken before they were adjusted. Either every product is
+// restored or, when one is missing, none is.
+func (s *jsonStore) RestoreQuantities(previous []domain.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return err
+	}
+	restored := 0
+	for i, p := range products {
+		for _, before := range previous {
+			if before.ID != p.ID || p.InTrash() {
+				continue
+			}
+			products[i].Quantity = before.Quantity
+			products[i].Stock = before.Stock
+			products[i].Lots = before.Lots
+			products[i].Expiration = before.Expiration
+			products[i].Version++
+			restored++
+		}
+	}
+	if restored != len(previous) {
+		return ErrNotFound
+	}
+	return s.saveProducts(products)
+}
+
+// moves units of a product between warehouses
+func (s *jsonStore) TransferQuantity(id int, fromWarehouseID int, toWarehouseID int, quantity int) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return domain.Product{}, err
+	}
+	for i, p := range products {
+		if p.ID != id || p.InTrash() {
+			continue
+		}
+		if err := products[i].MoveStock(fromWarehouseID, toWarehouseID, quantity); err != nil {
+			return domain.Product{}, fmt.Errorf("%w for product id: %d", ErrInsufficientStock, p.ID)
+		}
+		products[i].Version++
+		if err = s.saveProducts(products); err != nil {
+			return domain.Product{}, err
+		}
+		return products[i], nil
+	}
+	return domain.Product{}, ErrNotFound
+}
+
+// adds the units of a lot to a product
+func (s *jsonStore) ReceiveLot(id int, warehouseID int, lot domain.Lot) (domain.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.loadProducts()
+	if err != nil {
+		return domain.Product{}, err
+	}
+	for i, p := range products {
+		if p.ID != id || p.InTrash() {
+			continue
+		}
+		if err := products[i].ReceiveLot(warehouseID, lot); err != nil {
+			return domain.Product{}, err
+		}
+		products[i].Version++
+		if err = s.saveProducts(products); err != nil {
+			return domain.Product{}, err
+		}
+		return products[i], nil
+	}
+	return domain.Product{}, ErrNotFound
+}
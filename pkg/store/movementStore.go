@@ -0,0 +1,83 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type MovementStore interface {
+	GetAll() ([]domain.Movement, error)
+	AddMany(movements []domain.Movement) ([]domain.Movement, error)
+	saveMovements(movements []domain.Movement) error
+	loadMovements() ([]domain.Movement, error)
+}
+
+type jsonMovementStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads movements from JSON file
+func (s *jsonMovementStore) loadMovements() ([]domain.Movement, error) {
+	var movements []domain.Movement
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &movements)
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// saves movements to JSON file
+func (s *jsonMovementStore) saveMovements(movements []domain.Movement) error {
+	bytes, err := json.Marshal(movements)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new movement store
+func NewMovementStore(path string) MovementStore {
+	return &jsonMovementStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all movements
+func (s *jsonMovementStore) GetAll() ([]domain.Movement, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	movements, err := s.loadMovements()
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// appends movements, numbering them after the last stored one
+func (s *jsonMovementStore) AddMany(movements []domain.Movement) ([]domain.Movement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.loadMovements()
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if len(stored) > 0 {
+		next = stored[len(stored)-1].ID + 1
+	}
+	for i := range movements {
+		movements[i].ID = next + i
+	}
+	if err = s.saveMovements(append(stored, movements...)); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+type OrderStore interface {
+	GetAll() ([]domain.Order, error)
+	GetOne(id int) (domain.Order, error)
+	AddOne(order domain.Order) (int, error)
+	UpdateOne(order domain.Order) error
+	saveOrders(orders []domain.Order) error
+	loadOrders() ([]domain.Order, error)
+}
+
+type jsonOrderStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads orders from JSON file
+func (s *jsonOrderStore) loadOrders() ([]domain.Order, error) {
+	var orders []domain.Order
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &orders)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// saves orders to JSON file
+func (s *jsonOrderStore) saveOrders(orders []domain.Order) error {
+	bytes, err := json.Marshal(orders)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new order store
+func NewOrderStore(path string) OrderStore {
+	return &jsonOrderStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all orders
+func (s *jsonOrderStore) GetAll() ([]domain.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orders, err := s.loadOrders()
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// search order by id
+func (s *jsonOrderStore) GetOne(id int) (domain.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	orders, err := s.loadOrders()
+	if err != nil {
+		return domain.Order{}, err
+	}
+	for _, order := range orders {
+		if order.ID == id {
+			return order, nil
+		}
+	}
+	return domain.Order{}, ErrOrderNotFound
+}
+
+// adds a new order
+func (s *jsonOrderStore) AddOne(order domain.Order) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders, err := s.loadOrders()
+	if err != nil {
+		return 0, err
+	}
+	order.ID = 1
+	for _, o := range orders {
+		if o.ID >= order.ID {
+			order.ID = o.ID + 1
+		}
+	}
+	orders = append(orders, order)
+	if err = s.saveOrders(orders); err != nil {
+		return 0, err
+	}
+	return order.ID, nil
+}
+
+// updates an order
+func (s *jsonOrderStore) UpdateOne(order domain.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders, err := s.loadOrders()
+	if err != nil {
+		return err
+	}
+	for i, o := range orders {
+		if o.ID == order.ID {
+			orders[i] = order
+			return s.saveOrders(orders)
+		}
+	}
+	return ErrOrderNotFound
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrPriceListNotFound = errors.New("price list not found")
+
+type PriceListStore interface {
+	GetAll() ([]domain.PriceList, error)
+	GetOne(id int) (domain.PriceList, error)
+	AddOne(priceList domain.PriceList) (int, error)
+	UpdateOne(priceList domain.PriceList) error
+	DeleteOne(id int) error
+	savePriceLists(priceLists []domain.PriceList) error
+	loadPriceLists() ([]domain.PriceList, error)
+}
+
+type jsonPriceListStore struct {
+	pathToFile string
+}
+
+// loads price lists from JSON file
+func (s *jsonPriceListStore) loadPriceLists() ([]domain.PriceList, error) {
+	var priceLists []domain.PriceList
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &priceLists)
+	if err != nil {
+		return nil, err
+	}
+	return priceLists, nil
+}
+
+// saves price lists to JSON file
+func (s *jsonPriceListStore) savePriceLists(priceLists []domain.PriceList) error {
+	bytes, err := json.Marshal(priceLists)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new price list store
+func NewPriceListStore(path string) PriceListStore {
+	return &jsonPriceListStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all price lists
+func (s *jsonPriceListStore) GetAll() ([]domain.PriceList, error) {
+	priceLists, err := s.loadPriceLists()
+	if err != nil {
+		return nil, err
+	}
+	return priceLists, nil
+}
+
+// search price list by id
+func (s *jsonPriceListStore) GetOne(id int) (domain.PriceList, error) {
+	priceLists, err := s.loadPriceLists()
+	if err != nil {
+		return domain.PriceList{}, err
+	}
+	for _, priceList := range priceLists {
+		if priceList.ID == id {
+			return priceList, nil
+		}
+	}
+	return domain.PriceList{}, ErrPriceListNotFound
+}
+
+// adds a new price list
+func (s *jsonPriceListStore) AddOne(priceList domain.PriceList) (int, error) {
+	priceLists, err := s.loadPriceLists()
+	if err != nil {
+		return 0, err
+	}
+	priceList.ID = 1
+	for _, p := range priceLists {
+		if p.ID >= priceList.ID {
+			priceList.ID = p.ID + 1
+		}
+	}
+	priceLists = append(priceLists, priceList)
+	if err = s.savePriceLists(priceLists); err != nil {
+		return 0, err
+	}
+	return priceList.ID, nil
+}
+
+// updates a price list
+func (s *jsonPriceListStore) UpdateOne(priceList domain.PriceList) error {
+	priceLists, err := s.loadPriceLists()
+	if err != nil {
+		return err
+	}
+	for i, p := range priceLists {
+		if p.ID == priceList.ID {
+			priceLists[i] = priceList
+			return s.savePriceLists(priceLists)
+		}
+	}
+	return ErrPriceListNotFound
+}
+
+// deletes a price list
+func (s *jsonPriceListStore) DeleteOne(id int) error {
+	priceLists, err := s.loadPriceLists()
+	if err != nil {
+		return err
+	}
+	for i, p := range priceLists {
+		if p.ID == id {
+			priceLists = append(priceLists[:i], priceLists[i+1:]...)
+			return s.savePriceLists(priceLists)
+		}
+	}
+	return ErrPriceListNotFound
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+
+type PurchaseOrderStore interface {
+	GetAll() ([]domain.PurchaseOrder, error)
+	GetOne(id int) (domain.PurchaseOrder, error)
+	AddOne(purchaseOrder domain.PurchaseOrder) (int, error)
+	UpdateOne(purchaseOrder domain.PurchaseOrder) error
+	savePurchaseOrders(purchaseOrders []domain.PurchaseOrder) error
+	loadPurchaseOrders() ([]domain.PurchaseOrder, error)
+}
+
+type jsonPurchaseOrderStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads purchase orders from JSON file
+func (s *jsonPurchaseOrderStore) loadPurchaseOrders() ([]domain.PurchaseOrder, error) {
+	var purchaseOrders []domain.PurchaseOrder
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &purchaseOrders)
+	if err != nil {
+		return nil, err
+	}
+	return purchaseOrders, nil
+}
+
+// saves purchase orders to JSON file
+func (s *jsonPurchaseOrderStore) savePurchaseOrders(purchaseOrders []domain.PurchaseOrder) error {
+	bytes, err := json.Marshal(purchaseOrders)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new purchase order store
+func NewPurchaseOrderStore(path string) PurchaseOrderStore {
+	return &jsonPurchaseOrderStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all purchase orders
+func (s *jsonPurchaseOrderStore) GetAll() ([]domain.PurchaseOrder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	purchaseOrders, err := s.loadPurchaseOrders()
+	if err != nil {
+		return nil, err
+	}
+	return purchaseOrders, nil
+}
+
+// search purchase order by id
+func (s *jsonPurchaseOrderStore) GetOne(id int) (domain.PurchaseOrder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	purchaseOrders, err := s.loadPurchaseOrders()
+	if err != nil {
+		return domain.PurchaseOrder{}, err
+	}
+	for _, purchaseOrder := range purchaseOrders {
+		if purchaseOrder.ID == id {
+			return purchaseOrder, nil
+		}
+	}
+	return domain.PurchaseOrder{}, ErrPurchaseOrderNotFound
+}
+
+// adds a new purchase order
+func (s *jsonPurchaseOrderStore) AddOne(purchaseOrder domain.PurchaseOrder) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purchaseOrders, err := s.loadPurchaseOrders()
+	if err != nil {
+		return 0, err
+	}
+	purchaseOrder.ID = 1
+	for _, o := range purchaseOrders {
+		if o.ID >= purchaseOrder.ID {
+			purchaseOrder.ID = o.ID + 1
+		}
+	}
+	purchaseOrders = append(purchaseOrders, purchaseOrder)
+	if err = s.savePurchaseOrders(purchaseOrders); err != nil {
+		return 0, err
+	}
+	return purchaseOrder.ID, nil
+}
+
+// updates a purchase order
+func (s *jsonPurchaseOrderStore) UpdateOne(purchaseOrder domain.PurchaseOrder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purchaseOrders, err := s.loadPurchaseOrders()
+	if err != nil {
+		return err
+	}
+	for i, o := range purchaseOrders {
+		if o.ID == purchaseOrder.ID {
+			purchaseOrders[i] = purchaseOrder
+			return s.savePurchaseOrders(purchaseOrders)
+		}
+	}
+	return ErrPurchaseOrderNotFound
+}
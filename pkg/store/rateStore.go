@@ -0,0 +1,108 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+type RateStore interface {
+	GetAll() ([]domain.ExchangeRate, error)
+	GetOne(currency string) (domain.ExchangeRate, error)
+	SaveOne(rate domain.ExchangeRate) error
+	DeleteOne(currency string) error
+	saveRates(rates []domain.ExchangeRate) error
+	loadRates() ([]domain.ExchangeRate, error)
+}
+
+type jsonRateStore struct {
+	pathToFile string
+}
+
+// loads exchange rates from JSON file
+func (s *jsonRateStore) loadRates() ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &rates)
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// saves exchange rates to JSON file
+func (s *jsonRateStore) saveRates(rates []domain.ExchangeRate) error {
+	bytes, err := json.Marshal(rates)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new exchange rate store
+func NewRateStore(path string) RateStore {
+	return &jsonRateStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all exchange rates
+func (s *jsonRateStore) GetAll() ([]domain.ExchangeRate, error) {
+	rates, err := s.loadRates()
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// search exchange rate by currency code
+func (s *jsonRateStore) GetOne(currency string) (domain.ExchangeRate, error) {
+	rates, err := s.loadRates()
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	for _, rate := range rates {
+		if rate.Currency == currency {
+			return rate, nil
+		}
+	}
+	return domain.ExchangeRate{}, ErrRateNotFound
+}
+
+// adds or replaces an exchange rate
+func (s *jsonRateStore) SaveOne(rate domain.ExchangeRate) error {
+	rates, err := s.loadRates()
+	if err != nil {
+		return err
+	}
+	for i, r := range rates {
+		if r.Currency == rate.Currency {
+			rates[i] = rate
+			return s.saveRates(rates)
+		}
+	}
+	rates = append(rates, rate)
+	return s.saveRates(rates)
+}
+
+// deletes an exchange rate
+func (s *jsonRateStore) DeleteOne(currency string) error {
+	rates, err := s.loadRates()
+	if err != nil {
+		return err
+	}
+	for i, r := range rates {
+		if r.Currency == currency {
+			rates = append(rates[:i], rates[i+1:]...)
+			return s.saveRates(rates)
+		}
+	}
+	return ErrRateNotFound
+}
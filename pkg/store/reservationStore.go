@@ -0,0 +1,121 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrReservationNotFound = errors.New("reservation not found")
+
+type ReservationStore interface {
+	GetAll() ([]domain.Reservation, error)
+	GetOne(id int) (domain.Reservation, error)
+	AddOne(reservation domain.Reservation) (int, error)
+	UpdateOne(reservation domain.Reservation) error
+	saveReservations(reservations []domain.Reservation) error
+	loadReservations() ([]domain.Reservation, error)
+}
+
+type jsonReservationStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads reservations from JSON file
+func (s *jsonReservationStore) loadReservations() ([]domain.Reservation, error) {
+	var reservations []domain.Reservation
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &reservations)
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// saves reservations to JSON file
+func (s *jsonReservationStore) saveReservations(reservations []domain.Reservation) error {
+	bytes, err := json.Marshal(reservations)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new reservation store
+func NewReservationStore(path string) ReservationStore {
+	return &jsonReservationStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all reservations
+func (s *jsonReservationStore) GetAll() ([]domain.Reservation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reservations, err := s.loadReservations()
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// search reservation by id
+func (s *jsonReservationStore) GetOne(id int) (domain.Reservation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reservations, err := s.loadReservations()
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	for _, reservation := range reservations {
+		if reservation.ID == id {
+			return reservation, nil
+		}
+	}
+	return domain.Reservation{}, ErrReservationNotFound
+}
+
+// adds a new reservation
+func (s *jsonReservationStore) AddOne(reservation domain.Reservation) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reservations, err := s.loadReservations()
+	if err != nil {
+		return 0, err
+	}
+	reservation.ID = 1
+	for _, r := range reservations {
+		if r.ID >= reservation.ID {
+			reservation.ID = r.ID + 1
+		}
+	}
+	reservations = append(reservations, reservation)
+	if err = s.saveReservations(reservations); err != nil {
+		return 0, err
+	}
+	return reservation.ID, nil
+}
+
+// updates a reservation
+func (s *jsonReservationStore) UpdateOne(reservation domain.Reservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reservations, err := s.loadReservations()
+	if err != nil {
+		return err
+	}
+	for i, r := range reservations {
+		if r.ID == reservation.ID {
+			reservations[i] = reservation
+			return s.saveReservations(reservations)
+		}
+	}
+	return ErrReservationNotFound
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+type RevisionStore interface {
+	GetByProduct(productID int) ([]domain.Revision, error)
+	AddOne(product domain.Product, at time.Time) (int, error)
+	DeleteByProduct(productID int) error
+	saveRevisions(revisions []domain.Revision) error
+	loadRevisions() ([]domain.Revision, error)
+}
+
+type jsonRevisionStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads revisions from JSON file
+func (s *jsonRevisionStore) loadRevisions() ([]domain.Revision, error) {
+	var revisions []domain.Revision
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &revisions)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// saves revisions to JSON file
+func (s *jsonRevisionStore) saveRevisions(revisions []domain.Revision) error {
+	bytes, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new revision store
+func NewRevisionStore(path string) RevisionStore {
+	return &jsonRevisionStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves the revisions of a product, oldest first
+func (s *jsonRevisionStore) GetByProduct(productID int) ([]domain.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revisions, err := s.loadRevisions()
+	if err != nil {
+		return nil, err
+	}
+	var found = []domain.Revision{}
+	for _, revision := range revisions {
+		if revision.ProductID == productID {
+			found = append(found, revision)
+		}
+	}
+	return found, nil
+}
+
+// adds a product as its next revision, written at the given time
+func (s *jsonRevisionStore) AddOne(product domain.Product, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revisions, err := s.loadRevisions()
+	if err != nil {
+		return 0, err
+	}
+	revision := domain.Revision{ID: 1, ProductID: product.ID, Number: 1, Product: &product, CreatedAt: at}
+	for _, r := range revisions {
+		if r.ID >= revision.ID {
+			revision.ID = r.ID + 1
+		}
+		if r.ProductID == product.ID && r.Number >= revision.Number {
+			revision.Number = r.Number + 1
+		}
+	}
+	revisions = append(revisions, revision)
+	if err := s.saveRevisions(revisions); err != nil {
+		return 0, err
+	}
+	return revision.Number, nil
+}
+
+// deletes every revision of a product
+func (s *jsonRevisionStore) DeleteByProduct(productID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revisions, err := s.loadRevisions()
+	if err != nil {
+		return err
+	}
+	var kept = []domain.Revision{}
+	for _, r := range revisions {
+		if r.ProductID != productID {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(revisions) {
+		return nil
+	}
+	return s.saveRevisions(kept)
+}
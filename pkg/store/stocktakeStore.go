@@ -0,0 +1,121 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrStocktakeNotFound = errors.New("stocktake not found")
+
+type StocktakeStore interface {
+	GetAll() ([]domain.Stocktake, error)
+	GetOne(id int) (domain.Stocktake, error)
+	AddOne(stocktake domain.Stocktake) (int, error)
+	UpdateOne(stocktake domain.Stocktake) error
+	saveStocktakes(stocktakes []domain.Stocktake) error
+	loadStocktakes() ([]domain.Stocktake, error)
+}
+
+type jsonStocktakeStore struct {
+	pathToFile string
+	mu         sync.RWMutex
+}
+
+// loads stocktakes from JSON file
+func (s *jsonStocktakeStore) loadStocktakes() ([]domain.Stocktake, error) {
+	var stocktakes []domain.Stocktake
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &stocktakes)
+	if err != nil {
+		return nil, err
+	}
+	return stocktakes, nil
+}
+
+// saves stocktakes to JSON file
+func (s *jsonStocktakeStore) saveStocktakes(stocktakes []domain.Stocktake) error {
+	bytes, err := json.Marshal(stocktakes)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new stocktake store
+func NewStocktakeStore(path string) StocktakeStore {
+	return &jsonStocktakeStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all stocktakes
+func (s *jsonStocktakeStore) GetAll() ([]domain.Stocktake, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stocktakes, err := s.loadStocktakes()
+	if err != nil {
+		return nil, err
+	}
+	return stocktakes, nil
+}
+
+// search stocktake by id
+func (s *jsonStocktakeStore) GetOne(id int) (domain.Stocktake, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stocktakes, err := s.loadStocktakes()
+	if err != nil {
+		return domain.Stocktake{}, err
+	}
+	for _, stocktake := range stocktakes {
+		if stocktake.ID == id {
+			return stocktake, nil
+		}
+	}
+	return domain.Stocktake{}, ErrStocktakeNotFound
+}
+
+// adds a new stocktake
+func (s *jsonStocktakeStore) AddOne(stocktake domain.Stocktake) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stocktakes, err := s.loadStocktakes()
+	if err != nil {
+		return 0, err
+	}
+	stocktake.ID = 1
+	for _, r := range stocktakes {
+		if r.ID >= stocktake.ID {
+			stocktake.ID = r.ID + 1
+		}
+	}
+	stocktakes = append(stocktakes, stocktake)
+	if err = s.saveStocktakes(stocktakes); err != nil {
+		return 0, err
+	}
+	return stocktake.ID, nil
+}
+
+// updates a stocktake
+func (s *jsonStocktakeStore) UpdateOne(stocktake domain.Stocktake) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stocktakes, err := s.loadStocktakes()
+	if err != nil {
+		return err
+	}
+	for i, r := range stocktakes {
+		if r.ID == stocktake.ID {
+			stocktakes[i] = stocktake
+			return s.saveStocktakes(stocktakes)
+		}
+	}
+	return ErrStocktakeNotFound
+}
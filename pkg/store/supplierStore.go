@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrSupplierNotFound = errors.New("supplier not found")
+
+type SupplierStore interface {
+	GetAll() ([]domain.Supplier, error)
+	GetOne(id int) (domain.Supplier, error)
+	AddOne(supplier domain.Supplier) (int, error)
+	UpdateOne(supplier domain.Supplier) error
+	DeleteOne(id int) error
+	saveSuppliers(suppliers []domain.Supplier) error
+	loadSuppliers() ([]domain.Supplier, error)
+}
+
+type jsonSupplierStore struct {
+	pathToFile string
+}
+
+// loads suppliers from JSON file
+func (s *jsonSupplierStore) loadSuppliers() ([]domain.Supplier, error) {
+	var suppliers []domain.Supplier
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &suppliers)
+	if err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+// saves suppliers to JSON file
+func (s *jsonSupplierStore) saveSuppliers(suppliers []domain.Supplier) error {
+	bytes, err := json.Marshal(suppliers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new supplier store
+func NewSupplierStore(path string) SupplierStore {
+	return &jsonSupplierStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all suppliers
+func (s *jsonSupplierStore) GetAll() ([]domain.Supplier, error) {
+	suppliers, err := s.loadSuppliers()
+	if err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+// search supplier by id
+func (s *jsonSupplierStore) GetOne(id int) (domain.Supplier, error) {
+	suppliers, err := s.loadSuppliers()
+	if err != nil {
+		return domain.Supplier{}, err
+	}
+	for _, supplier := range suppliers {
+		if supplier.ID == id {
+			return supplier, nil
+		}
+	}
+	return domain.Supplier{}, ErrSupplierNotFound
+}
+
+// adds a new supplier
+func (s *jsonSupplierStore) AddOne(supplier domain.Supplier) (int, error) {
+	suppliers, err := s.loadSuppliers()
+	if err != nil {
+		return 0, err
+	}
+	supplier.ID = 1
+	for _, w := range suppliers {
+		if w.ID >= supplier.ID {
+			supplier.ID = w.ID + 1
+		}
+	}
+	suppliers = append(suppliers, supplier)
+	if err = s.saveSuppliers(suppliers); err != nil {
+		return 0, err
+	}
+	return supplier.ID, nil
+}
+
+// updates a supplier
+func (s *jsonSupplierStore) UpdateOne(supplier domain.Supplier) error {
+	suppliers, err := s.loadSuppliers()
+	if err != nil {
+		return err
+	}
+	for i, w := range suppliers {
+		if w.ID == supplier.ID {
+			suppliers[i] = supplier
+			return s.saveSuppliers(suppliers)
+		}
+	}
+	return ErrSupplierNotFound
+}
+
+// deletes a supplier
+func (s *jsonSupplierStore) DeleteOne(id int) error {
+	suppliers, err := s.loadSuppliers()
+	if err != nil {
+		return err
+	}
+	for i, w := range suppliers {
+		if w.ID == id {
+			suppliers = append(suppliers[:i], suppliers[i+1:]...)
+			return s.saveSuppliers(suppliers)
+		}
+	}
+	return ErrSupplierNotFound
+}
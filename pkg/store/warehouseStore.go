@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/hernan-hdiaz/go-web/internal/domain"
+)
+
+var ErrWarehouseNotFound = errors.New("warehouse not found")
+
+type WarehouseStore interface {
+	GetAll() ([]domain.Warehouse, error)
+	GetOne(id int) (domain.Warehouse, error)
+	AddOne(warehouse domain.Warehouse) (int, error)
+	UpdateOne(warehouse domain.Warehouse) error
+	DeleteOne(id int) error
+	saveWarehouses(warehouses []domain.Warehouse) error
+	loadWarehouses() ([]domain.Warehouse, error)
+}
+
+type jsonWarehouseStore struct {
+	pathToFile string
+}
+
+// loads warehouses from JSON file
+func (s *jsonWarehouseStore) loadWarehouses() ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	file, err := os.ReadFile(s.pathToFile)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(file), &warehouses)
+	if err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// saves warehouses to JSON file
+func (s *jsonWarehouseStore) saveWarehouses(warehouses []domain.Warehouse) error {
+	bytes, err := json.Marshal(warehouses)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.pathToFile, bytes, 0644)
+}
+
+// creates a new warehouse store
+func NewWarehouseStore(path string) WarehouseStore {
+	return &jsonWarehouseStore{
+		pathToFile: path,
+	}
+}
+
+// retrieves all warehouses
+func (s *jsonWarehouseStore) GetAll() ([]domain.Warehouse, error) {
+	warehouses, err := s.loadWarehouses()
+	if err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// search warehouse by id
+func (s *jsonWarehouseStore) GetOne(id int) (domain.Warehouse, error) {
+	warehouses, err := s.loadWarehouses()
+	if err != nil {
+		return domain.Warehouse{}, err
+	}
+	for _, warehouse := range warehouses {
+		if warehouse.ID == id {
+			return warehouse, nil
+		}
+	}
+	return domain.Warehouse{}, ErrWarehouseNotFound
+}
+
+// adds a new warehouse
+func (s *jsonWarehouseStore) AddOne(warehouse domain.Warehouse) (int, error) {
+	warehouses, err := s.loadWarehouses()
+	if err != nil {
+		return 0, err
+	}
+	warehouse.ID = 1
+	for _, w := range warehouses {
+		if w.ID >= warehouse.ID {
+			warehouse.ID = w.ID + 1
+		}
+	}
+	warehouses = append(warehouses, warehouse)
+	if err = s.saveWarehouses(warehouses); err != nil {
+		return 0, err
+	}
+	return warehouse.ID, nil
+}
+
+// updates a warehouse
+func (s *jsonWarehouseStore) UpdateOne(warehouse domain.Warehouse) error {
+	warehouses, err := s.loadWarehouses()
+	if err != nil {
+		return err
+	}
+	for i, w := range warehouses {
+		if w.ID == warehouse.ID {
+			warehouses[i] = warehouse
+			return s.saveWarehouses(warehouses)
+		}
+	}
+	return ErrWarehouseNotFound
+}
+
+// deletes a warehouse
+func (s *jsonWarehouseStore) DeleteOne(id int) error {
+	warehouses, err := s.loadWarehouses()
+	if err != nil {
+		return err
+	}
+	for i, w := range warehouses {
+		if w.ID == id {
+			warehouses = append(warehouses[:i], warehouses[i+1:]...)
+			return s.saveWarehouses(warehouses)
+		}
+	}
+	return ErrWarehouseNotFound
+}
@@ -0,0 +1,24 @@
+package web
+
+import "context"
+
+// ActorKey is the context key holding who is calling the API
+const ActorKey = "actor"
+
+// DefaultActor identifies callers that are not authenticated
+const DefaultActor = "anonymous"
+
+// TokenActor is the actor the shared TOKEN belongs to
+const TokenActor = "api"
+
+// ProductIDKey is the context key handlers store the ID of a product they
+// created under, for the audit log
+const ProductIDKey = "product_id"
+
+// returns the caller stored in ctx by the authentication middleware
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(ActorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return DefaultActor
+}
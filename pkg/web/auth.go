@@ -0,0 +1,45 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrCredentials = errors.New("credentials must be actor:token pairs separated by commas")
+
+// Credentials maps each API token to the actor it belongs to
+type Credentials map[string]string
+
+// ParseCredentials reads a list of actor:token pairs separated by commas
+func ParseCredentials(list string) (Credentials, error) {
+	credentials := Credentials{}
+	for _, pair := range strings.Split(list, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		actor, token, ok := strings.Cut(pair, ":")
+		actor, token = strings.TrimSpace(actor), strings.TrimSpace(token)
+		if !ok || actor == "" || token == "" {
+			return nil, ErrCredentials
+		}
+		credentials[token] = actor
+	}
+	return credentials, nil
+}
+
+// Authenticate is a middleware letting in callers whose token header is one
+// of the credentials, storing the actor it belongs to for the handlers
+func Authenticate(credentials Credentials) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := credentials[c.GetHeader("token")]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+			return
+		}
+		c.Set(ActorKey, actor)
+		c.Next()
+	}
+}
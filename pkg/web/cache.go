@@ -0,0 +1,73 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const jsonContentType = "application/json; charset=utf-8"
+
+// writes a successful response tagged with the entity tag etag makes from
+// its body, and with Last-Modified unless it is zero. Callers whose
+// If-None-Match header, or If-Modified-Since header when there is none,
+// shows their cached copy is still current get Not Modified with no body.
+//
+// Last-Modified only counts whole seconds, so it is left out while the
+// current second has not ended: a write later in it would go unnoticed.
+func Conditional(ctx *gin.Context, data interface{}, etag func(body []byte) string, lastModified time.Time) {
+	if !lastModified.Before(time.Now().Truncate(time.Second)) {
+		lastModified = time.Time{}
+	}
+	body, err := json.Marshal(response{Data: data})
+	if err != nil {
+		Failure(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	tag := etag(body)
+	ctx.Header("ETag", tag)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(ctx.Request, tag, lastModified) {
+		ctx.Status(http.StatusNotModified)
+		ctx.Writer.WriteHeaderNow()
+		return
+	}
+	ctx.Data(http.StatusOK, jsonContentType, body)
+}
+
+// writes a successful response tagged with the entity tag of a resource at a
+// version, the same one reads of it are tagged with
+func Tagged(ctx *gin.Context, status int, version int, data interface{}) {
+	body, err := json.Marshal(response{Data: data})
+	if err != nil {
+		Failure(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Header("ETag", RepresentationETag(version, body))
+	ctx.Data(status, jsonContentType, body)
+}
+
+// reports whether the cached copy a conditional request holds is current
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if candidates := entityTags(req.Header.Values("If-None-Match")); len(candidates) > 0 {
+		for _, candidate := range candidates {
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrETag = errors.New("not a strong ETag of a version")
+
+// returns the entity tag of a representation of a resource at a version. It
+// changes with the version and with anything else the body is made from.
+func RepresentationETag(version int, body []byte) string {
+	return strconv.Quote(strconv.Itoa(version) + "-" + digest(body))
+}
+
+// returns a weak entity tag for a body
+func WeakETag(body []byte) string {
+	return "W/" + strconv.Quote(digest(body))
+}
+
+// returns the version of a resource a strong entity tag was made for
+func Version(etag string) (int, error) {
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, ErrETag
+	}
+	version, _, _ := strings.Cut(unquoted, "-")
+	number, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, ErrETag
+	}
+	return number, nil
+}
+
+// returns the versions the If-Match header of a request accepts, nil when
+// any version will do. Weak and unknown tags never match, so a header made
+// of them only accepts none.
+func IfMatch(ctx *gin.Context) []int {
+	tags := entityTags(ctx.Request.Header.Values("If-Match"))
+	if len(tags) == 0 {
+		return nil
+	}
+	var versions = []int{}
+	for _, tag := range tags {
+		if tag == "*" {
+			return nil
+		}
+		if version, err := Version(tag); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// splits If-Match and If-None-Match headers into the entity tags they list
+func entityTags(headers []string) []string {
+	var tags []string
+	for _, header := range headers {
+		quoted := false
+		start := 0
+		for i := 0; i <= len(header); i++ {
+			if i < len(header) && header[i] == '"' {
+				quoted = !quoted
+			}
+			if i == len(header) || (header[i] == ',' && !quoted) {
+				if tag := strings.TrimSpace(header[start:i]); tag != "" {
+					tags = append(tags, tag)
+				}
+				start = i + 1
+			}
+		}
+	}
+	return tags
+}
+
+// a short hash of a body
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:8])
+}
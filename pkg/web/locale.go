@@ -0,0 +1,51 @@
+package web
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// returns the locales a caller asked for, most preferred first. The lang
+// query param comes before the Accept-Language header, whose entries are
+// ordered by their quality.
+func Locales(ctx *gin.Context) []string {
+	var locales []string
+	if lang := ctx.Query("lang"); lang != "" {
+		locales = append(locales, lang)
+	}
+
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(ctx.GetHeader("Accept-Language"), ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		accepted = append(accepted, weighted{locale: locale, quality: quality})
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+	for _, a := range accepted {
+		locales = append(locales, a.locale)
+	}
+	return locales
+}